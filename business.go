@@ -0,0 +1,190 @@
+package tart
+
+import (
+	"time"
+)
+
+// Holiday is implemented by relations marking a holiday, a day business day
+// arithmetic skips.
+type Holiday interface {
+	Relation
+	Holiday() bool
+}
+
+type holiday struct {
+	Relation
+}
+
+func (holiday) Holiday() bool { return true }
+
+type recurrentHoliday struct {
+	Recurrent
+}
+
+func (recurrentHoliday) Holiday() bool { return true }
+
+func (h holiday) Period(t time.Time) Interval {
+	return period(h.Relation, t)
+}
+
+func (h recurrentHoliday) Period(t time.Time) Interval {
+	return period(h.Recurrent, t)
+}
+
+// AsHoliday returns the provided Relation marked as a holiday, remaining
+// Recurrent when provided a Recurrent relation.
+func AsHoliday(rl Relation) Relation {
+	if rc, ok := rl.(Recurrent); ok {
+		return recurrentHoliday{rc}
+	}
+	return holiday{rl}
+}
+
+func isHoliday(rl Relation) bool {
+	h, ok := rl.(Holiday)
+	return ok && h.Holiday()
+}
+
+// holidays returns the holidays set on the instance.
+func (r *relations) holidays() map[string]Relation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ret := make(map[string]Relation)
+	for k, v := range r.storedRelation {
+		if isHoliday(v) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.In(a.Location()).Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// holidayDays is the days of the holidays of an instance relative to an
+// anchor, in a location, found a year at a time as they are asked after.
+type holidayDays struct {
+	t      *Tart
+	anchor time.Time
+	loc    *time.Location
+	hs     map[string]Relation
+	years  map[int]map[time.Time]string
+}
+
+func (t *Tart) holidayDays(anchor time.Time, loc *time.Location) *holidayDays {
+	return &holidayDays{t, anchor, loc, t.holidays(), make(map[int]map[time.Time]string)}
+}
+
+// dayKey returns the day of the provided time in the provided location, at
+// midnight UTC.
+func dayKey(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// year returns the holidays falling in the provided year, by day.
+func (h *holidayDays) year(y int) map[time.Time]string {
+	if ret, ok := h.years[y]; ok {
+		return ret
+	}
+	ret := make(map[time.Time]string)
+	from := time.Date(y, time.January, 1, 0, 0, 0, 0, h.loc)
+	to := from.AddDate(1, 0, 0)
+	for k, v := range h.hs {
+		c := &Context{Time: h.anchor, Tart: h.t, Directive: &Directive{origin: k, phrase: k}}
+		o := v.Relative(c)()
+		if c.err != nil {
+			continue
+		}
+		rc, ok := v.(Recurrent)
+		if !ok {
+			if !o.Before(from) && o.Before(to) {
+				ret[dayKey(o, h.loc)] = k
+			}
+			continue
+		}
+		for o = following(rc, o, from); !o.Before(from) && o.Before(to); {
+			ret[dayKey(o, h.loc)] = k
+			nx := rc.Step(o, 1)
+			if !nx.After(o) {
+				break
+			}
+			o = nx
+		}
+	}
+	h.years[y] = ret
+	return ret
+}
+
+// on returns the key of a holiday falling on the day of the provided time, or
+// an empty string.
+func (h *holidayDays) on(day time.Time) string {
+	d := dayKey(day, h.loc)
+	return h.year(d.Year())[d]
+}
+
+func (h *holidayDays) isBusinessDay(day time.Time) bool {
+	switch day.In(h.loc).Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	return h.on(day) == ""
+}
+
+// IsBusinessDay reports whether the day of the provided time is a business
+// day, a weekday not a holiday set on the instance.
+func (t *Tart) IsBusinessDay(day time.Time) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.holidayDays(t.Time, day.Location()).isBusinessDay(day)
+}
+
+func (t *Tart) addBusinessDays(anchor, from time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	h := t.holidayDays(anchor, from.Location())
+	for n > 0 {
+		from = from.AddDate(0, 0, step)
+		if h.isBusinessDay(from) {
+			n--
+		}
+	}
+	return from
+}
+
+// AddBusinessDays returns the provided time moved by n business days,
+// backward for negative n, keeping the clock.
+func (t *Tart) AddBusinessDays(from time.Time, n int) time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.addBusinessDays(t.Time, from, n)
+}
+
+// BusinessDaysBetween returns the number of business days from the day of a
+// up to, not including, the day of b, negative when b is before a.
+func (t *Tart) BusinessDaysBetween(a, b time.Time) int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	sign := 1
+	if b.Before(a) {
+		a, b, sign = b, a, -1
+	}
+	var n int
+	h := t.holidayDays(t.Time, a.Location())
+	end := startOfDay(b.In(a.Location()))
+	for d := startOfDay(a); d.Before(end); d = d.AddDate(0, 0, 1) {
+		if h.isBusinessDay(d) {
+			n++
+		}
+	}
+	return sign * n
+}
@@ -0,0 +1,295 @@
+package tart
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// calendarPrefix is the prefix of a point given as a systemd calendar event,
+// e.g. "!oncalendar:Mon..Fri *-*-* 09:00".
+const calendarPrefix = "oncalendar:"
+
+// calendarEvent is a systemd calendar event, as of OnCalendar timers, a
+// schedule of the times matching each of its components.
+type calendarEvent struct {
+	years                           map[int]bool
+	month, day, dow, hour, min, sec uint64
+	fromEnd                         bool
+	loc                             *time.Location
+}
+
+var calendarShorthands = map[string]string{
+	"minutely":     "*-*-* *:*:00",
+	"hourly":       "*-*-* *:00:00",
+	"daily":        "*-*-* 00:00:00",
+	"weekly":       "Mon *-*-* 00:00:00",
+	"monthly":      "*-*-01 00:00:00",
+	"yearly":       "*-01-01 00:00:00",
+	"annually":     "*-01-01 00:00:00",
+	"quarterly":    "*-01,04,07,10-01 00:00:00",
+	"semiannually": "*-01,07-01 00:00:00",
+}
+
+func calendarError(in, msg string, a ...interface{}) error {
+	return fmt.Errorf("calendar event '%s': %s", in, fmt.Sprintf(msg, a...))
+}
+
+// parseCalendar parses the provided systemd calendar event, of the form
+// "[weekdays] [year-month-day] [hour:minute[:second]] [timezone]", or a
+// shorthand such as "weekly".
+func parseCalendar(in string) (*calendarEvent, error) {
+	expr := strings.TrimSpace(in)
+	if s, ok := calendarShorthands[strings.ToLower(expr)]; ok {
+		expr = s
+	}
+	fs := strings.Fields(expr)
+	if len(fs) == 0 {
+		return nil, calendarError(in, "empty")
+	}
+	e := &calendarEvent{month: 1<<13 - 2, day: 1<<32 - 2, dow: 1<<7 - 1, hour: 1, min: 1, sec: 1}
+	if l := fs[len(fs)-1]; len(fs) > 1 && strings.IndexFunc(l, unicode.IsLetter) >= 0 {
+		loc, err := time.LoadLocation(l)
+		if err != nil {
+			return nil, calendarError(in, "unknown timezone '%s'", l)
+		}
+		e.loc, fs = loc, fs[:len(fs)-1]
+	}
+	if unicode.IsLetter(rune(fs[0][0])) {
+		dow, err := parseCalendarWeekdays(fs[0])
+		if err != nil {
+			return nil, calendarError(in, "weekdays '%s': %s", fs[0], err)
+		}
+		e.dow, fs = dow, fs[1:]
+	}
+	var hasDate, hasTime bool
+	for _, f := range fs {
+		var err error
+		switch {
+		case strings.Contains(f, ":") && !hasTime:
+			err, hasTime = e.parseTime(f), true
+		case !strings.Contains(f, ":") && !hasDate:
+			err, hasDate = e.parseDate(f), true
+		default:
+			err = fmt.Errorf("unexpected")
+		}
+		if err != nil {
+			return nil, calendarError(in, "'%s': %s", f, err)
+		}
+	}
+	return e, nil
+}
+
+var calendarWeekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+func calendarWeekday(v string) (int, error) {
+	l := strings.ToLower(v)
+	for i, n := range calendarWeekdays {
+		if l == n || l == strings.ToLower(time.Weekday(i).String()) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday '%s'", v)
+}
+
+func parseCalendarWeekdays(in string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(in, ",") {
+		lh := strings.SplitN(part, "..", 2)
+		lo, err := calendarWeekday(lh[0])
+		if err != nil {
+			return 0, err
+		}
+		hi := lo
+		if len(lh) == 2 {
+			if hi, err = calendarWeekday(lh[1]); err != nil {
+				return 0, err
+			}
+		}
+		for i := lo; ; i = (i + 1) % 7 {
+			set |= 1 << uint(i)
+			if i == hi {
+				break
+			}
+		}
+	}
+	return set, nil
+}
+
+// parseComponent returns the values of a calendar event component, a list of
+// '*', values and ranges "a..b", each optionally repeating "/n", nil for '*'.
+func parseComponent(in string, min, max int) ([]int, error) {
+	if in == "*" {
+		return nil, nil
+	}
+	var ret []int
+	for _, part := range strings.Split(in, ",") {
+		rng, step := part, 0
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid repetition '%s'", part[i+1:])
+			}
+			rng = part[:i]
+		}
+		lo, hi := min, max
+		lh := strings.SplitN(rng, "..", 2)
+		if lh[0] != "*" {
+			var err error
+			if lo, err = strconv.Atoi(lh[0]); err != nil || lo < min || lo > max {
+				return nil, fmt.Errorf("'%s' out of range %d..%d", lh[0], min, max)
+			}
+			hi = lo
+			if len(lh) == 2 {
+				if hi, err = strconv.Atoi(lh[1]); err != nil || hi < lo || hi > max {
+					return nil, fmt.Errorf("invalid range '%s'", rng)
+				}
+			} else if step > 0 {
+				hi = max
+			}
+		}
+		if step == 0 {
+			step = 1
+		}
+		for v := lo; v <= hi; v += step {
+			ret = append(ret, v)
+		}
+	}
+	return ret, nil
+}
+
+func componentBits(in string, min, max int, set *uint64) error {
+	vs, err := parseComponent(in, min, max)
+	if err != nil {
+		return err
+	}
+	if vs == nil {
+		for v := min; v <= max; v++ {
+			vs = append(vs, v)
+		}
+	}
+	*set = 0
+	for _, v := range vs {
+		*set |= 1 << uint(v)
+	}
+	return nil
+}
+
+// parseDate parses "year-month-day", "month-day", or either with '~' before
+// the day, counting days from the end of the month, "~01" the last.
+func (e *calendarEvent) parseDate(in string) error {
+	sep := "-"
+	if strings.Contains(in, "~") {
+		sep, e.fromEnd = "~", true
+	}
+	i := strings.LastIndex(in, sep)
+	if i < 0 {
+		return fmt.Errorf("malformed date")
+	}
+	ym := strings.Split(in[:i], "-")
+	if len(ym) > 2 {
+		return fmt.Errorf("malformed date")
+	}
+	if len(ym) == 2 {
+		ys, err := parseComponent(ym[0], 1970, 2199)
+		if err != nil {
+			return err
+		}
+		if ys != nil {
+			e.years = make(map[int]bool)
+			for _, y := range ys {
+				e.years[y] = true
+			}
+		}
+	}
+	if err := componentBits(ym[len(ym)-1], 1, 12, &e.month); err != nil {
+		return err
+	}
+	return componentBits(in[i+1:], 1, 31, &e.day)
+}
+
+// parseTime parses "hour:minute" or "hour:minute:second".
+func (e *calendarEvent) parseTime(in string) error {
+	hms := strings.Split(in, ":")
+	if len(hms) < 2 || len(hms) > 3 {
+		return fmt.Errorf("malformed time")
+	}
+	if len(hms) == 2 {
+		hms = append(hms, "00")
+	}
+	if err := componentBits(hms[0], 0, 23, &e.hour); err != nil {
+		return err
+	}
+	if err := componentBits(hms[1], 0, 59, &e.min); err != nil {
+		return err
+	}
+	return componentBits(hms[2], 0, 59, &e.sec)
+}
+
+func (e *calendarEvent) matchMonth(y int, m time.Month) bool {
+	return (e.years == nil || e.years[y]) && has(e.month, int(m))
+}
+
+func (e *calendarEvent) dayMatch(t time.Time) bool {
+	d := t.Day()
+	if e.fromEnd {
+		d = time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day() - d + 1
+	}
+	return has(e.day, d) && has(e.dow, int(t.Weekday()))
+}
+
+func (e *calendarEvent) matchHour(h int) bool    { return has(e.hour, h) }
+func (e *calendarEvent) matchMinute(mi int) bool { return has(e.min, mi) }
+func (e *calendarEvent) matchSecond(s int) bool  { return has(e.sec, s) }
+
+func (e *calendarEvent) in(t time.Time) time.Time {
+	if e.loc != nil {
+		return t.In(e.loc)
+	}
+	return t
+}
+
+func (e *calendarEvent) next(t time.Time) (time.Time, bool) {
+	o, ok := nextMatch(e, e.in(t))
+	if !ok {
+		return time.Time{}, false
+	}
+	return o.In(t.Location()), true
+}
+
+func (e *calendarEvent) prev(t time.Time) (time.Time, bool) {
+	o, ok := prevMatch(e, e.in(t))
+	if !ok {
+		return time.Time{}, false
+	}
+	return o.In(t.Location()), true
+}
+
+// SetOnCalendar sets relation k recurring as the provided systemd calendar
+// event, as given to OnCalendar in timer units, e.g.
+//
+//	t.SetOnCalendar("standup", "Mon..Fri *-*-* 09:00:00")
+//	t.SetOnCalendar("rotate", "*-*-01 00:00")
+//	t.SetOnCalendar("report", "weekly")
+//
+// Events are of the form "[weekdays] [year-month-day] [hour:minute[:second]]
+// [timezone]". Weekdays are a list of names and ranges "Mon..Fri". Each
+// other component is a list of '*', values and ranges "a..b", each optionally
+// repeating "/n", e.g. "*:0/15" every quarter hour; '~' in place of the '-'
+// before the day counts days from the end of the month, "*-02~01" the last
+// day of february. An omitted date is every day, an omitted time midnight,
+// omitted seconds 0. The shorthands "minutely", "hourly", "daily", "weekly",
+// "monthly", "quarterly", "semiannually" and "yearly" stand for their events.
+// Times match in the given timezone, else the location of the anchor.
+//
+// A point prefixed "oncalendar:" is evaluated as the event following without
+// setting a relation, e.g. "!oncalendar:Sat,Sun 10:00".
+func (t *Tart) SetOnCalendar(k, expr string) error {
+	e, err := parseCalendar(expr)
+	if err != nil {
+		return err
+	}
+	return t.SetRelation(k, scheduleRelation(e))
+}
@@ -0,0 +1,266 @@
+package tart
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronPrefix is the prefix of a point given as a cron expression, e.g.
+// "!cron:0 9 * * MON".
+const cronPrefix = "cron:"
+
+// maxCalendarYears bounds the years searched for an occurrence of a cron
+// expression, calendar event or recurrence rule, which may never occur, e.g.
+// "0 0 30 2 *".
+const maxCalendarYears = 100
+
+// cron is a cron expression, a schedule of the times matching each of its
+// fields, as bit sets.
+type cron struct {
+	sec, min, hour, dom, month, dow uint64
+	domStar, dowStar                bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{"second", 0, 59, nil},
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"", "JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{"day of week", 0, 7, []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT", "SUN"}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+func cronError(in, msg string, a ...interface{}) error {
+	return fmt.Errorf("cron expression '%s': %s", in, fmt.Sprintf(msg, a...))
+}
+
+// parseCron parses the provided cron expression, of five fields, minute, hour,
+// day of month, month and day of week, or six led by second, or a macro such
+// as "@daily".
+func parseCron(in string) (*cron, error) {
+	expr := strings.TrimSpace(in)
+	if m, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+	fs := strings.Fields(expr)
+	switch len(fs) {
+	case 5:
+		fs = append([]string{"0"}, fs...)
+	case 6:
+	default:
+		return nil, cronError(in, "expected 5 or 6 fields, got %d", len(fs))
+	}
+	var c cron
+	sets := []*uint64{&c.sec, &c.min, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, f := range fs {
+		set, err := cronFields[i].parse(f)
+		if err != nil {
+			return nil, cronError(in, "%s field '%s': %s", cronFields[i].name, f, err)
+		}
+		*sets[i] = set
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = fs[3] == "*" || fs[3] == "?"
+	c.dowStar = fs[5] == "*" || fs[5] == "?"
+	return &c, nil
+}
+
+func (f cronField) value(v string) (int, error) {
+	for i, n := range f.names {
+		if n != "" && strings.EqualFold(v, n) {
+			return i, nil
+		}
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < f.min || i > f.max {
+		return 0, fmt.Errorf("'%s' out of range %d-%d", v, f.min, f.max)
+	}
+	return i, nil
+}
+
+// parse returns the bit set of the values of the provided field, a list of
+// '*', values, ranges "a-b", each optionally stepped "/n".
+func (f cronField) parse(in string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(in, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step '%s'", part[i+1:])
+			}
+			rng = part[:i]
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			lh := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = f.value(lh[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(lh[1]); err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("range '%s' ends before it starts", rng)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rng); err != nil {
+				return 0, err
+			}
+			if step == 1 {
+				hi = lo
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+func (c *cron) dayMatch(t time.Time) bool {
+	d, w := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return d && w
+	}
+	return d || w
+}
+
+func (c *cron) matchMonth(_ int, m time.Month) bool { return has(c.month, int(m)) }
+func (c *cron) matchHour(h int) bool                { return has(c.hour, h) }
+func (c *cron) matchMinute(mi int) bool             { return has(c.min, mi) }
+func (c *cron) matchSecond(s int) bool              { return has(c.sec, s) }
+
+func (c *cron) next(t time.Time) (time.Time, bool) {
+	return nextMatch(c, t)
+}
+
+func (c *cron) prev(t time.Time) (time.Time, bool) {
+	return prevMatch(c, t)
+}
+
+// calendarMatcher matches times field by field, a schedule of whole seconds
+// through nextMatch and prevMatch.
+type calendarMatcher interface {
+	matchMonth(int, time.Month) bool
+	dayMatch(time.Time) bool
+	matchHour(int) bool
+	matchMinute(int) bool
+	matchSecond(int) bool
+}
+
+// nextMatch returns the first time not before the provided time matched by
+// the provided matcher, skipping ahead by the largest unmatched field.
+func nextMatch(c calendarMatcher, t time.Time) (time.Time, bool) {
+	if tt := t.Truncate(time.Second); tt.Before(t) {
+		t = tt.Add(time.Second)
+	}
+	limit := t.Year() + maxCalendarYears
+	for t.Year() <= limit {
+		y, m, d := t.Date()
+		h, mi, s := t.Clock()
+		loc := t.Location()
+		var n time.Time
+		switch {
+		case !c.matchMonth(y, m):
+			n = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatch(t):
+			n = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case !c.matchHour(h):
+			n = time.Date(y, m, d, h+1, 0, 0, 0, loc)
+		case !c.matchMinute(mi):
+			n = time.Date(y, m, d, h, mi+1, 0, 0, loc)
+		case !c.matchSecond(s):
+			n = time.Date(y, m, d, h, mi, s+1, 0, loc)
+		default:
+			return t, true
+		}
+		if !n.After(t) {
+			n = t.Add(time.Second)
+		}
+		t = n
+	}
+	return time.Time{}, false
+}
+
+// prevMatch returns the last time before the provided time matched by the
+// provided matcher, skipping back by the largest unmatched field.
+func prevMatch(c calendarMatcher, t time.Time) (time.Time, bool) {
+	t = t.Add(-time.Nanosecond).Truncate(time.Second)
+	limit := t.Year() - maxCalendarYears
+	for t.Year() >= limit {
+		y, m, d := t.Date()
+		h, mi, s := t.Clock()
+		loc := t.Location()
+		var n time.Time
+		switch {
+		case !c.matchMonth(y, m):
+			n = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatch(t):
+			n = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		case !c.matchHour(h):
+			n = time.Date(y, m, d, h, 0, 0, 0, loc)
+		case !c.matchMinute(mi):
+			n = time.Date(y, m, d, h, mi, 0, 0, loc)
+		case !c.matchSecond(s):
+			n = time.Date(y, m, d, h, mi, s, 0, loc)
+		default:
+			return t, true
+		}
+		if !n.Before(t) {
+			n = t
+		}
+		t = n.Add(-time.Second)
+	}
+	return time.Time{}, false
+}
+
+// SetCron sets relation k recurring as the provided cron expression, e.g.
+//
+//	t.SetCron("backup", "0 3 * * 1-5")
+//
+// Expressions are of five fields, minute, hour, day of month, month and day of
+// week, or six led by second, each a list of '*', values, ranges "a-b", each
+// optionally stepped "/n", with months and days of week also by three letter
+// name. As with cron, a day matching either a restricted day of month or a
+// restricted day of week matches. The macros "@yearly", "@monthly",
+// "@weekly", "@daily" and "@hourly" stand for their expressions. Times match
+// in the location of the anchor.
+//
+// A point prefixed "cron:" is evaluated as the expression following without
+// setting a relation, e.g. ">1h!cron:0 9 * * MON".
+func (t *Tart) SetCron(k, expr string) error {
+	c, err := parseCron(expr)
+	if err != nil {
+		return err
+	}
+	return t.SetRelation(k, scheduleRelation(c))
+}
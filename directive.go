@@ -2,25 +2,158 @@ package tart
 
 import (
 	"bytes"
+	"fmt"
 	"math"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 	"unicode"
 )
 
-type directive struct {
-	origin string
-	shift  []*shiftFrag
-	phrase string
+// Directive is a compiled directive, the point phrase and the shift/iter
+// fragments modifying it. A Directive is immutable once compiled and may be
+// evaluated against any number of Tart instances or anchor times.
+type Directive struct {
+	origin   string
+	shift    []*shiftFrag
+	phrase   string
+	phraseAt int
+	sel      selector
+	opts     []string
+	monthEnd MonthEnd
+	err      error
+}
+
+// selector selects which occurrence of a recurrent point a directive starts
+// from.
+type selector int
+
+const (
+	selNext    selector = iota // the next occurrence, the default
+	selLast                    // the latest occurrence before the anchor, "last:"
+	selNearest                 // the occurrence nearest the anchor, "~"
+)
+
+const lastPrefix = "last:"
+
+// quotedPhrase reports whether a point phrase is quoted in the canonical form
+// of a directive, being empty, starting with a quote or with a prefix read as
+// more than the point, e.g. the point "last:monday" as opposed to the last
+// monday, or a schedule prefix.
+func quotedPhrase(phrase string) bool {
+	if phrase == "" || strings.HasPrefix(phrase, `"`) || strings.HasPrefix(phrase, lastPrefix) {
+		return true
+	}
+	_, prefixed, _ := prefixRelation(phrase)
+	return prefixed
 }
 
-func (d *directive) calcShifts() {
+func (d *Directive) calcShifts() {
 	for _, v := range d.shift {
-		v.calcDur()
+		if err := v.calcDur(); err != nil && d.err == nil {
+			err.Directive = d.origin
+			d.err = err
+		}
+	}
+}
+
+// Compile parses the provided directive string (see Tart.Get for the form of
+// a directive), returning a *ParseError for malformed modifiers. Whether the
+// point resolves is not known until evaluation against a Tart instance.
+func Compile(in string) (*Directive, error) {
+	d := parse(in)
+	if d.err != nil {
+		return nil, d.err
 	}
+	return d, nil
+}
+
+// Point returns the point phrase of the directive, "now" when none was given.
+func (d *Directive) Point() string {
+	return d.phrase
 }
 
-func (d *directive) Shift() []*shifter {
+// Modifiers returns the shift/iter fragments of the directive in order of
+// appearance, e.g. ">>1h<1d!eod" gives [">>1h", "<1d"].
+func (d *Directive) Modifiers() []string {
+	ret := make([]string, 0, len(d.shift))
+	for _, v := range d.shift {
+		ret = append(ret, v.String())
+	}
+	return ret
+}
+
+// String returns the canonical form of the directive, with modifiers followed
+// by an explicit point, e.g. "tuesday" gives "!tuesday" and ">>1w" gives
+// ">>1w!now".
+func (d *Directive) String() string {
+	var b strings.Builder
+	for _, v := range d.opts {
+		b.WriteByte(tOption)
+		b.WriteString(v)
+	}
+	for _, v := range d.shift {
+		b.WriteString(v.String())
+	}
+	b.WriteByte(tPoint)
+	if d.sel == selLast {
+		b.WriteString(lastPrefix)
+	}
+	if quotedPhrase(d.phrase) {
+		b.WriteString(strconv.Quote(d.phrase))
+	} else {
+		b.WriteString(d.phrase)
+	}
+	return b.String()
+}
+
+// Eval returns the time of the directive relative to the provided Tart
+// instance, using the relations defined on that instance.
+func (d *Directive) Eval(t *Tart) (time.Time, error) {
+	return t.eval(d)
+}
+
+// EvalAt returns the time of the directive relative to the provided time,
+// using only the default relations.
+func (d *Directive) EvalAt(tt time.Time) (time.Time, error) {
+	t, err := New(func(t *Tart) error { t.Time = tt; return nil })
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.Eval(t)
+}
+
+// steps returns the number of occurrences of the point iters step through,
+// where "+" is the next occurrence, i.e. the point itself, "++" the one after
+// that and "-" the occurrence before the point.
+func (d *Directive) steps() int {
+	var n int
+	for _, v := range d.shift {
+		n = n + v.iter
+	}
+	if n > 0 {
+		n = n - 1
+	}
+	return n
+}
+
+// bare returns the directive stripped of modifiers.
+func (d *Directive) bare() *Directive {
+	return &Directive{origin: d.phrase, phrase: d.phrase, phraseAt: d.phraseAt, sel: selNext}
+}
+
+// unshifted returns the directive stripped of the durations of its modifiers,
+// keeping its iters and selector.
+func (d *Directive) unshifted() *Directive {
+	n := &Directive{origin: d.origin, phrase: d.phrase, phraseAt: d.phraseAt, sel: d.sel, monthEnd: d.monthEnd}
+	for _, v := range d.shift {
+		n.shift = append(n.shift, &shiftFrag{iter: v.iter})
+	}
+	return n
+}
+
+func (d *Directive) shifters() []*shifter {
 	if len(d.shift) > 0 {
 		var ret []*shifter
 		for _, v := range d.shift {
@@ -37,17 +170,33 @@ const (
 	tShiftLeft  byte = '<'
 	tShiftRight byte = '>'
 	tPoint      byte = '!'
+	tNearest    byte = '~'
+	tOption     byte = '@'
 )
 
-func isPhrase(b byte) bool {
-	var ret bool
-	switch {
-	case b == tIterPlus, b == tIterMinus, b == tShiftLeft, b == tShiftRight, b == tPoint:
-		ret = false
-	default:
-		ret = true
-	}
-	return ret
+// ParseError reports a malformed directive, locating the offending fragment
+// by byte offset within the directive.
+type ParseError struct {
+	Directive string
+	Offset    int
+	Fragment  string
+	Msg       string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%q: %s %q at %d", e.Directive, e.Msg, e.Fragment, e.Offset)
+}
+
+func unknownUnitError(unit string) *ParseError {
+	return &ParseError{Fragment: unit, Msg: "unknown unit"}
+}
+
+func missingUnitError(digits string) *ParseError {
+	return &ParseError{Fragment: digits, Msg: "missing unit"}
+}
+
+func unknownPointError(d *Directive) *ParseError {
+	return &ParseError{d.origin, d.phraseAt, d.phrase, "unknown point"}
 }
 
 func isToken(b byte, t ...byte) bool {
@@ -59,32 +208,44 @@ func isToken(b byte, t ...byte) bool {
 	return false
 }
 
+func isSign(b byte) bool {
+	return isToken(b, tShiftRight, tShiftLeft, tIterPlus, tIterMinus, tNearest)
+}
+
 type prs struct {
-	currShift  *shiftFrag
+	in         string
 	shifts     []*shiftFrag
-	inPhrase   bool
 	currPhrase *phraseFrag
+	sel        selector
+	opts       []string
+	err        *ParseError
 }
 
-func newPrs() *prs {
+func newPrs(in string) *prs {
 	return &prs{
-		currShift:  &shiftFrag{0, make([]byte, 0), nil},
+		in:         in,
 		shifts:     make([]*shiftFrag, 0),
-		inPhrase:   true,
-		currPhrase: &phraseFrag{make([]byte, 0)},
+		currPhrase: &phraseFrag{0, false, make([]byte, 0)},
+	}
+}
+
+func (p *prs) fail(at int, frag, msg string) {
+	if p.err == nil {
+		p.err = &ParseError{p.in, at, frag, msg}
 	}
 }
 
 type shiftFrag struct {
-	count int
+	count int // signs stepping by the duration
+	iter  int // signs stepping by occurrence of the point
+	at    int
+	signs []byte
 	sD    []byte
 	res   []*shifter
 }
 
-func (s *shiftFrag) append(b byte) {
-	if isPhrase(b) {
-		s.sD = append(s.sD, b)
-	}
+func (s *shiftFrag) String() string {
+	return string(s.signs) + string(s.sD)
 }
 
 func durString(s *shiftFrag) string {
@@ -95,7 +256,7 @@ func durString(s *shiftFrag) string {
 	return ds
 }
 
-func (s *shiftFrag) calcDur() {
+func (s *shiftFrag) calcDur() *ParseError {
 	res := make([]*shifter, 0)
 	cabs := int(math.Abs(float64(s.count)))
 	switch {
@@ -112,21 +273,51 @@ func (s *shiftFrag) calcDur() {
 		res = append(res, newShifter("0s", 1))
 	}
 	s.res = res
+	for _, v := range res {
+		if v.err != nil {
+			v.err.Offset = s.at
+			return v.err
+		}
+	}
+	return nil
 }
 
 type shifter struct {
-	origin  string
-	y, m, d int
-	dur     time.Duration
-	err     error
+	origin string
+	Period
+	wall, abs time.Duration // clock units shifting by wall clock and absolutely
+	bd        int
+	err       *ParseError
+}
+
+func isClockUnit(unit []byte) bool {
+	switch string(unit) {
+	case "ns", "us", "µs", "μs", "ms", "s", "m", "h":
+		return true
+	}
+	return false
 }
 
 func newShifter(in string, dir int) *shifter {
+	if strings.HasPrefix(in, "P") {
+		p, pErr := ParsePeriod(in)
+		var err *ParseError
+		if pErr != nil {
+			err = &ParseError{Fragment: in, Msg: "invalid period"}
+		}
+		if dir < 0 {
+			p = p.Negate()
+		}
+		return &shifter{in, p, 0, 0, 0, err}
+	}
 	// alternating numbers and strings
-	var y, m, d int
-	var accum int     // accumulates digits
-	var unit []byte   // accumulates units
-	var unproc []byte // accumulate unprocessed durations to return
+	var y, m, d, bd int
+	var accum int        // accumulates digits
+	var unit []byte      // accumulates units
+	var unproc []byte    // accumulate unprocessed durations to return
+	var wall, abs []byte // likewise, of wall clock and absolute units
+	var frac []byte      // the whole part of a fractional clock unit
+	var err *ParseError
 
 	unitComplete := func() {
 		// NOTE: compare byte slices because some units, i.e. ms, are multi-rune
@@ -138,6 +329,10 @@ func newShifter(in string, dir int) *shifter {
 			bytes.Equal(unit, []byte{'w', 'e', 'e', 'k'}) ||
 			bytes.Equal(unit, []byte{'w', 'e', 'e', 'k', 's'}) {
 			d += 7 * accum
+		} else if bytes.Equal(unit, []byte{'b', 'd'}) ||
+			bytes.Equal(unit, []byte{'b', 'd', 'a', 'y'}) ||
+			bytes.Equal(unit, []byte{'b', 'd', 'a', 'y', 's'}) {
+			bd += accum
 		} else if bytes.Equal(unit, []byte{'m', 'o'}) ||
 			bytes.Equal(unit, []byte{'m', 'o', 'n'}) ||
 			bytes.Equal(unit, []byte{'m', 'o', 'n', 't', 'h'}) ||
@@ -148,78 +343,98 @@ func newShifter(in string, dir int) *shifter {
 			bytes.Equal(unit, []byte{'y', 'e', 'a', 'r'}) ||
 			bytes.Equal(unit, []byte{'y', 'e', 'a', 'r', 's'}) {
 			y += accum
-		} else {
-			unproc = append(append(unproc, strconv.Itoa(accum)...), unit...)
+		} else if bytes.Equal(unit, []byte{'.'}) {
+			// NOTE: a lone '.' is the decimal point of a fractional clock
+			// unit, e.g. 1.5h, and is left for time.ParseDuration
+			frac = append(strconv.AppendInt(frac, int64(accum), 10), '.')
+		} else if isClockUnit(unit) {
+			unproc = append(append(append(unproc, frac...), strconv.Itoa(accum)...), unit...)
+			frac = nil
+		} else if (unit[0] == 'w' || unit[0] == 'a') && isClockUnit(unit[1:]) {
+			buf := &wall
+			if unit[0] == 'a' {
+				buf = &abs
+			}
+			*buf = append(append(append(*buf, frac...), strconv.Itoa(accum)...), unit[1:]...)
+			frac = nil
+		} else if err == nil {
+			err = unknownUnitError(string(unit))
 		}
 	}
 
-	expectDigit := true
+	var digits []byte // digits not yet followed by a unit
 	for _, rune := range in {
 		if unicode.IsDigit(rune) {
-			if expectDigit {
-				accum = accum*10 + int(rune-'0')
-			} else {
+			if len(unit) > 0 {
 				unitComplete()
 				unit = unit[:0]
-				accum = int(rune - '0')
+				accum = 0
+				digits = digits[:0]
 			}
+			accum = accum*10 + int(rune-'0')
+			digits = append(digits, byte(rune))
 			continue
 		}
 		unit = append(unit, string(rune)...)
-		expectDigit = false
 	}
-	if len(unit) > 0 {
+	switch {
+	case len(unit) > 0:
 		unitComplete()
 		accum = 0
 		unit = unit[:0]
+	case len(digits) > 0 && err == nil:
+		err = missingUnitError(string(digits))
 	}
 
-	remaining, err := time.ParseDuration(string(unproc))
+	if len(frac) > 0 && err == nil {
+		err = &ParseError{Fragment: in, Msg: "invalid duration"}
+	}
+	var durs [3]time.Duration
+	for i, v := range [][]byte{unproc, wall, abs} {
+		if len(v) > 0 {
+			var pErr error
+			durs[i], pErr = time.ParseDuration(string(v))
+			if pErr != nil && err == nil {
+				err = &ParseError{Fragment: in, Msg: "invalid duration"}
+			}
+		}
+	}
+	remaining, wallDur, absDur := durs[0], durs[1], durs[2]
 
 	if dir < 0 {
 		y = -y
 		m = -m
 		d = -d
+		bd = -bd
 		remaining = -remaining
+		wallDur = -wallDur
+		absDur = -absDur
 	}
 
-	return &shifter{in, y, m, d, remaining, err}
+	return &shifter{in, Period{y, m, d, remaining}, wallDur, absDur, bd, err}
 }
 
 type phraseFrag struct {
+	at     int
+	quoted bool
 	phrase []byte
 }
 
-func (p *phraseFrag) append(b byte) {
-	if isPhrase(b) {
-		p.phrase = append(p.phrase, b)
-	}
-}
-
 func (p *phraseFrag) String() string {
 	np := string(p.phrase)
-	if np == "" {
+	if np == "" && !p.quoted {
 		np = "now"
 	}
 	return np
 }
 
-func parse(in string) *directive {
-	d := &directive{origin: in}
-	p := newPrs()
+func parse(in string) *Directive {
+	d := &Directive{origin: in}
+	p := newPrs(in)
+	var state stateFn = lexStart
 	idx := 0
-	for idx <= len(in)-1 {
-		var jump int
-		for _, idfn := range idFns() {
-			add := idfn(idx, in, p)
-			jump = jump + add
-		}
-		switch {
-		case jump > 0:
-			idx = idx + jump
-		default:
-			idx++
-		}
+	for state != nil {
+		state, idx = state(p, idx)
 	}
 	for _, fn := range calcFns() {
 		fn(d, p)
@@ -227,45 +442,115 @@ func parse(in string) *directive {
 	return d
 }
 
-type idFn func(int, string, *prs) int
+// stateFn lexes the directive from idx, returning the state to continue in
+// and the index to continue from. A nil stateFn ends lexing.
+type stateFn func(*prs, int) (stateFn, int)
 
-func idFns() []idFn {
-	return []idFn{
-		idShift,
-		idPhrase,
+// lexStart begins a directive, which is either modifiers, an explicit point
+// or a bare phrase standing for the point.
+func lexStart(p *prs, idx int) (stateFn, int) {
+	switch {
+	case idx >= len(p.in):
+		return nil, idx
+	case isSign(p.in[idx]):
+		return lexModifier, idx
+	case p.in[idx] == tPoint:
+		return lexPoint, idx + 1
+	case p.in[idx] == tOption:
+		return lexOption, idx
 	}
+	return lexPhrase, idx
 }
 
-func idShift(idx int, in string, p *prs) int {
-	if isToken(in[idx], tShiftRight, tShiftLeft, tIterPlus, tIterMinus) {
-		p.inPhrase = false
-		s := idx
-		stop := false
-		for !stop {
-			shiftVal := vShift(in[s])
-			switch {
-			case shiftVal == 0:
-				stopG := false
-				for !stopG {
-					switch {
-					case s > len(in)-1, isToken(in[s], tPoint, tShiftRight, tShiftLeft, tIterPlus, tIterMinus):
-						stopG = true
-						p.shifts = append(p.shifts, p.currShift)
-						p.currShift = &shiftFrag{0, make([]byte, 0), nil}
-					default:
-						p.currShift.append(in[s])
-						s++
-					}
-				}
-				stop = true
-			case shiftVal != 0:
-				p.currShift.count = p.currShift.count + shiftVal
-				s++
-			}
+// lexOption reads an option, '@' and a word, e.g. "@clamp".
+func lexOption(p *prs, idx int) (stateFn, int) {
+	end := idx + 1
+	for end < len(p.in) && unicode.IsLetter(rune(p.in[end])) {
+		end++
+	}
+	p.opts = append(p.opts, p.in[idx+1:end])
+	return lexStart, end
+}
+
+// lexModifier collects a run of signs and the duration following them.
+func lexModifier(p *prs, idx int) (stateFn, int) {
+	sf := &shiftFrag{0, 0, 0, make([]byte, 0), make([]byte, 0), nil}
+	var shifts bool
+	for idx < len(p.in) && isSign(p.in[idx]) {
+		switch p.in[idx] {
+		case tShiftRight, tShiftLeft:
+			sf.count = sf.count + vShift(p.in[idx])
+			shifts = true
+		case tNearest:
+			p.sel = selNearest
+		default:
+			sf.iter = sf.iter + vShift(p.in[idx])
 		}
-		return s - idx
+		sf.signs = append(sf.signs, p.in[idx])
+		idx++
+	}
+	sf.at = idx
+	for idx < len(p.in) && !isSign(p.in[idx]) && p.in[idx] != tPoint && p.in[idx] != tOption {
+		sf.sD = append(sf.sD, p.in[idx])
+		idx++
+	}
+	// NOTE: iters given a duration and no shift to carry it step by that
+	// duration, as a shift would, e.g. "+++1h"
+	if !shifts && len(sf.sD) > 0 {
+		sf.count, sf.iter = sf.iter, 0
+	}
+	p.shifts = append(p.shifts, sf)
+	switch {
+	case idx >= len(p.in):
+		return nil, idx
+	case p.in[idx] == tPoint:
+		return lexPoint, idx + 1
+	case p.in[idx] == tOption:
+		return lexOption, idx
+	}
+	return lexModifier, idx
+}
+
+// lexPoint begins the point, everything after which is phrase text.
+func lexPoint(p *prs, idx int) (stateFn, int) {
+	if idx < len(p.in) && p.in[idx] == '"' {
+		return lexQuoted, idx
+	}
+	return lexPhrase, idx
+}
+
+func lexPhrase(p *prs, idx int) (stateFn, int) {
+	p.currPhrase.at = idx
+	p.currPhrase.phrase = append(p.currPhrase.phrase, p.in[idx:]...)
+	return nil, len(p.in)
+}
+
+// lexQuoted reads a double quoted phrase, with the escapes of a Go string
+// literal.
+func lexQuoted(p *prs, idx int) (stateFn, int) {
+	p.currPhrase.at = idx
+	end := idx + 1
+	for end < len(p.in) && p.in[end] != '"' {
+		if p.in[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(p.in) {
+		p.fail(idx, p.in[idx:], "unterminated quote")
+		return nil, len(p.in)
 	}
-	return 0
+	uq, err := strconv.Unquote(p.in[idx : end+1])
+	if err != nil {
+		p.fail(idx, p.in[idx:end+1], "invalid quote")
+		return nil, len(p.in)
+	}
+	if end+1 < len(p.in) {
+		p.fail(end+1, p.in[end+1:], "unexpected text after quote")
+	}
+	p.currPhrase.quoted = true
+	p.currPhrase.phrase = append(p.currPhrase.phrase, uq...)
+	return nil, len(p.in)
 }
 
 func vShift(b byte) int {
@@ -281,35 +566,53 @@ func vShift(b byte) int {
 	return ret
 }
 
-func idPhrase(idx int, in string, p *prs) int {
-	b := in[idx]
-	if b == tPoint {
-		p.inPhrase = true
-	}
-	if p.inPhrase {
-		p.currPhrase.append(b)
-	}
-
-	return 0
-}
-
-type calcFn func(*directive, *prs)
+type calcFn func(*Directive, *prs)
 
 func calcFns() []calcFn {
 	return []calcFn{
-		func(d *directive, p *prs) {
+		func(d *Directive, p *prs) {
 			d.phrase = p.currPhrase.String()
+			d.phraseAt = p.currPhrase.at
+			d.sel = p.sel
+			if !p.currPhrase.quoted && strings.HasPrefix(d.phrase, lastPrefix) {
+				if d.sel == selNearest {
+					p.fail(d.phraseAt, lastPrefix, "conflicting selector")
+				}
+				d.phrase = strings.TrimPrefix(d.phrase, lastPrefix)
+				d.phraseAt = d.phraseAt + len(lastPrefix)
+				d.sel = selLast
+			}
 		},
-		func(d *directive, p *prs) {
+		func(d *Directive, p *prs) {
+			d.opts = p.opts
+			for _, v := range p.opts {
+				me, ok := monthEndOptions[v]
+				if !ok {
+					p.fail(strings.Index(p.in, string(tOption)+v), string(tOption)+v, "unknown option")
+					continue
+				}
+				if d.monthEnd != 0 && d.monthEnd != me {
+					p.fail(strings.Index(p.in, string(tOption)+v), string(tOption)+v, "conflicting option")
+				}
+				d.monthEnd = me
+			}
+		},
+		func(d *Directive, p *prs) {
 			d.shift = p.shifts
 			d.calcShifts()
 		},
+		func(d *Directive, p *prs) {
+			if p.err != nil && d.err == nil {
+				d.err = p.err
+			}
+		},
 	}
 }
 
+// directives caches compiled directives by string, safe for concurrent use.
 type directives struct {
-	d    map[string]*directive
-	last *directive
+	mu sync.RWMutex
+	d  map[string]*Directive
 }
 
 func newDirectives() *directives {
@@ -318,20 +621,23 @@ func newDirectives() *directives {
 	return d
 }
 
-func (d *directives) getDirective(k string) *directive {
+func (d *directives) getDirective(k string) *Directive {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	if gd, ok := d.d[k]; ok {
-		d.last = gd
 		return gd
 	}
 	return nil
 }
 
-func (d *directives) setDirective(k string, v *directive) {
+func (d *directives) setDirective(k string, v *Directive) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.d[k] = v
-	d.last = v
 }
 
 func (d *directives) reset() {
-	d.d = make(map[string]*directive)
-	d.last = nil
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.d = make(map[string]*Directive)
 }
@@ -0,0 +1,110 @@
+package tart
+
+import (
+	"time"
+)
+
+// Distance is the calendar distance between two times, negative throughout
+// where the second is before the first.
+type Distance struct {
+	From, To time.Time
+	// The calendar period from From to To, as Period.AddTo adds it: the
+	// clock duration, then whole years, months and days.
+	Period
+	// The distance in a single unit, whole units only.
+	TotalMonths, TotalWeeks, TotalDays int
+	// The business days from the day of From up to the day of To, as
+	// BusinessDaysBetween.
+	BusinessDays int
+}
+
+// Between returns the calendar distance from the time of directive a to the
+// time of directive b, e.g.
+//
+//	t.Between("!now", "!renewal").TotalMonths
+//
+// The Duration moves the time of a to the clock of b, then months and days
+// are counted as time.AddDate and Period.AddTo add them, so that the time of a
+// plus the Period is the time of b, backward from a where b is before. Days
+// keep the clock across daylight saving changes, and a month from january 31
+// overflows to march 3 (or 2) as AddDate does, so january 31 to february 28
+// is 28 days, not a month. The clock is that of b in the location of a.
+// Between never fails, as Get. Use BetweenE to be told about malformed
+// directives.
+func (t *Tart) Between(a, b string) Distance {
+	ret, _ := t.BetweenE(a, b)
+	return ret
+}
+
+// BetweenE is Between, returning a *ParseError as GetE.
+func (t *Tart) BetweenE(a, b string) (Distance, error) {
+	ta, errA := t.GetE(a)
+	tb, errB := t.GetE(b)
+	ret := distance(ta, tb)
+	ret.BusinessDays = t.BusinessDaysBetween(ta, tb)
+	if errA != nil {
+		return ret, errA
+	}
+	return ret, errB
+}
+
+// passes reports whether t is past b going the way of the provided sign.
+func passes(t, b time.Time, sign int) bool {
+	if sign < 0 {
+		return t.Before(b)
+	}
+	return t.After(b)
+}
+
+// wholeDays returns the whole days from a toward b, by date, as AddDate adds
+// them, not passing b.
+func wholeDays(a, b time.Time, sign int) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	n := int(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	if n != 0 && passes(a.AddDate(0, 0, n), b, sign) {
+		n -= sign
+	}
+	return n
+}
+
+// atClock returns the first time from a, going the way of the provided sign,
+// at the clock of b.
+func atClock(a, b time.Time, sign int) time.Time {
+	x := time.Date(a.Year(), a.Month(), a.Day(), b.Hour(), b.Minute(), b.Second(), b.Nanosecond(), a.Location())
+	if passes(a, x, sign) {
+		x = time.Date(a.Year(), a.Month(), a.Day()+sign, b.Hour(), b.Minute(), b.Second(), b.Nanosecond(), a.Location())
+	}
+	return x
+}
+
+func distance(a, b time.Time) Distance {
+	sign := 1
+	if b.Before(a) {
+		sign = -1
+	}
+	to := b.In(a.Location())
+	x := atClock(a, to, sign)
+	months := (to.Year()-x.Year())*12 + int(to.Month()-x.Month())
+	for months != 0 && passes(x.AddDate(0, months, 0), to, sign) {
+		months -= sign
+	}
+	for !passes(x.AddDate(0, months+sign, 0), to, sign) {
+		months += sign
+	}
+	days := wholeDays(x.AddDate(0, months, 0), to, sign)
+	total := wholeDays(a, to, sign)
+	return Distance{
+		From: a,
+		To:   b,
+		Period: Period{
+			Years:    months / 12,
+			Months:   months % 12,
+			Days:     days,
+			Duration: x.Sub(a),
+		},
+		TotalMonths: months,
+		TotalWeeks:  total / 7,
+		TotalDays:   total,
+	}
+}
@@ -13,20 +13,140 @@ func HolidaysBase(t *Tart) error {
 
 func holidaysBase(*Tart) map[string]Relation {
 	return map[string]Relation{
-		"christmas": newRelation(christmas()),
+		"christmas": AsHoliday(WithPeriod(Recurring(christmas(), Every(1, 0, 0)), DayOf)),
 	}
 }
 
 func christmas() RelativeFunc {
-	return func(t *Tart) TimeFunc {
+	return func(t *Context) TimeFunc {
 		yr := t.Year()
 		xmas := time.Date(yr, time.December, 25, 12, 0, 0, 0, t.Location())
 		if t.After(xmas) {
-			time.Date(yr+1, time.December, 25, 12, 0, 0, 0, t.Location())
+			xmas = time.Date(yr+1, time.December, 25, 12, 0, 0, 0, t.Location())
 		}
-		xmas = pumpShift(xmas, t.last)
+		xmas = t.Shift(xmas)
 		return func() time.Time {
 			return xmas
 		}
 	}
 }
+
+// yearRange bounds the years searched for an occurrence of a dateRule.
+const yearRange = 400
+
+// dateRule returns the date of a yearly holiday in the provided year, and
+// whether the holiday is held in that year.
+type dateRule func(year int, loc *time.Location) (time.Time, bool)
+
+// next returns the first occurrence of the rule not before the day of the
+// provided time.
+func (r dateRule) next(t time.Time) (time.Time, bool) {
+	sod := startOfDay(t)
+	for y := t.Year() - 1; y <= t.Year()+yearRange; y++ {
+		if o, ok := r(y, t.Location()); ok && !o.Before(sod) {
+			return o, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// year returns the year of the rule the provided occurrence belongs to,
+// which differs from the year of the occurrence where an observed rule moves
+// a holiday across new year.
+func (r dateRule) year(t time.Time) int {
+	for _, y := range []int{t.Year(), t.Year() + 1, t.Year() - 1} {
+		if o, ok := r(y, t.Location()); ok && sameDay(o, t) {
+			return y
+		}
+	}
+	return t.Year()
+}
+
+// step is the StepFunc of the rule, skipping years the holiday is not held.
+func (r dateRule) step(t time.Time, n int) time.Time {
+	y, dir := r.year(t), 1
+	if n < 0 {
+		dir, n = -1, -n
+	}
+	for i := 0; n > 0 && i < yearRange; i++ {
+		y = y + dir
+		if o, ok := r(y, t.Location()); ok {
+			t, n = o, n-1
+		}
+	}
+	return t
+}
+
+// relative is the RelativeFunc of the rule, the next occurrence from the
+// anchor, shifted. A rule not held again fails the Context.
+func (r dateRule) relative(c *Context) TimeFunc {
+	o, ok := r.next(c.Time)
+	if ok {
+		o = c.Shift(o)
+	} else {
+		c.fail(noOccurrenceError(c))
+	}
+	return func() time.Time {
+		return o
+	}
+}
+
+// relation returns the rule as a Recurrent relation, each occurrence a day.
+func (r dateRule) relation() Recurrent {
+	return WithPeriod(Recurring(r.relative, r.step), DayOf)
+}
+
+// fixedDate is the rule of a holiday held on the same date every year.
+func fixedDate(m time.Month, d int) dateRule {
+	return func(y int, loc *time.Location) (time.Time, bool) {
+		return time.Date(y, m, d, 0, 0, 0, 0, loc), true
+	}
+}
+
+// nthWeekday is the rule of a holiday held on the nth weekday of a month,
+// counting from the end of the month for negative n, e.g. -1 is the last.
+// The holiday is not held in years the month has no nth weekday, e.g. a
+// fifth friday of february.
+func nthWeekday(m time.Month, wd time.Weekday, n int) dateRule {
+	return func(y int, loc *time.Location) (time.Time, bool) {
+		if n < 0 {
+			last := time.Date(y, m+1, 0, 0, 0, 0, 0, loc)
+			back := (int(last.Weekday()) - int(wd) + 7) % 7
+			o := last.AddDate(0, 0, -back+7*(n+1))
+			return o, o.Month() == m
+		}
+		first := time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		fwd := (int(wd) - int(first.Weekday()) + 7) % 7
+		o := first.AddDate(0, 0, fwd+7*(n-1))
+		return o, o.Month() == m
+	}
+}
+
+// observed is the rule of the day a holiday is observed on, moving a holiday
+// on Saturday to the Friday before and on Sunday to the Monday after.
+func observed(r dateRule) dateRule {
+	return func(y int, loc *time.Location) (time.Time, bool) {
+		o, ok := r(y, loc)
+		switch o.Weekday() {
+		case time.Saturday:
+			o = o.AddDate(0, 0, -1)
+		case time.Sunday:
+			o = o.AddDate(0, 0, 1)
+		}
+		return o, ok
+	}
+}
+
+// since is the rule of a holiday first held in the provided year.
+func since(first int, r dateRule) dateRule {
+	return during(first, 0, r)
+}
+
+// during is the rule of a holiday held from the first to the last year
+// provided, inclusive, where a zero year is unbounded.
+func during(first, last int, r dateRule) dateRule {
+	return func(y int, loc *time.Location) (time.Time, bool) {
+		o, ok := r(y, loc)
+		return o, ok && (first == 0 || y >= first) && (last == 0 || y <= last)
+	}
+}
@@ -0,0 +1,125 @@
+package tart
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// HolidaysDE returns a Config setting the public holidays of the provided
+// German federal state, by its two letter code, e.g. "BY" for Bavaria or "BE"
+// for Berlin. Holidays kept only in some communities of a state, such as
+// Mariä Himmelfahrt in Bavaria or the Augsburger Friedensfest, are not set.
+// Reformationstag is set in every state for 2017, its 500th anniversary,
+// when it was held nationwide.
+func HolidaysDE(state string) Config {
+	return func(t *Tart) error {
+		hs, err := holidaysDE(t, state)
+		if err != nil {
+			return err
+		}
+		return t.SetBatch(hs)
+	}
+}
+
+func stateError(state string) error {
+	return fmt.Errorf("'%s' is not a German federal state", state)
+}
+
+var statesDE = []string{
+	"BW", "BY", "BE", "BB", "HB", "HH", "HE", "MV",
+	"NI", "NW", "RP", "SL", "SN", "ST", "SH", "TH",
+}
+
+// inStates maps the provided states to the year 0, held in every year.
+func inStates(states ...string) map[string]int {
+	ret := make(map[string]int, len(states))
+	for _, v := range states {
+		ret[v] = 0
+	}
+	return ret
+}
+
+var holidaysTableDE = []struct {
+	key    string
+	rule   dateRule
+	states map[string]int // the first year held, by state; nil where nationwide
+}{
+	{"neujahr", fixedDate(time.January, 1), nil},
+	{"heilige-drei-koenige", fixedDate(time.January, 6), inStates("BW", "BY", "ST")},
+	{"frauentag", fixedDate(time.March, 8), map[string]int{"BE": 2019, "MV": 2023}},
+	{"karfreitag", fromRule(easter, -2), nil},
+	{"ostersonntag", easter, inStates("BB")},
+	{"ostermontag", fromRule(easter, 1), nil},
+	{"tag-der-arbeit", fixedDate(time.May, 1), nil},
+	{"christi-himmelfahrt", fromRule(easter, 39), nil},
+	{"pfingstsonntag", fromRule(easter, 49), inStates("BB")},
+	{"pfingstmontag", fromRule(easter, 50), nil},
+	{"fronleichnam", fromRule(easter, 60), inStates("BW", "BY", "HE", "NW", "RP", "SL")},
+	{"mariae-himmelfahrt", fixedDate(time.August, 15), inStates("SL")},
+	{"weltkindertag", fixedDate(time.September, 20), map[string]int{"TH": 2019}},
+	{"tag-der-deutschen-einheit", fixedDate(time.October, 3), nil},
+	{"reformationstag", fixedDate(time.October, 31), map[string]int{
+		"BB": 0, "MV": 0, "SN": 0, "ST": 0, "TH": 0,
+		"HB": 2018, "HH": 2018, "NI": 2018, "SH": 2018,
+	}},
+	{"allerheiligen", fixedDate(time.November, 1), inStates("BW", "BY", "NW", "RP", "SL")},
+	{"buss-und-bettag", bussUndBettag, inStates("SN")},
+	{"weihnachten", fixedDate(time.December, 25), nil},
+	{"zweiter-weihnachtstag", fixedDate(time.December, 26), nil},
+}
+
+// nationwideDE are the years a holiday of only some states was held in every
+// state, by key.
+var nationwideDE = map[string]int{
+	"reformationstag": 2017,
+}
+
+func holidaysDE(_ *Tart, state string) (map[string]Relation, error) {
+	var known bool
+	for _, v := range statesDE {
+		known = known || v == state
+	}
+	if !known {
+		return nil, stateError(state)
+	}
+	ret := make(map[string]Relation)
+	for _, v := range holidaysTableDE {
+		rule := v.rule
+		if v.states != nil {
+			first, ok := v.states[state]
+			once, nationwide := nationwideDE[v.key]
+			if !ok && !nationwide {
+				continue
+			}
+			if !ok {
+				first = math.MaxInt
+			}
+			rule = since(first, rule)
+			if nationwide {
+				rule = alsoIn(once, rule, v.rule)
+			}
+		}
+		ret[v.key] = AsHoliday(rule.relation())
+	}
+	return ret, nil
+}
+
+// alsoIn is the rule of a holiday held by the provided rule, and by the
+// nationwide rule in the provided year.
+func alsoIn(year int, r, nationwide dateRule) dateRule {
+	return func(y int, loc *time.Location) (time.Time, bool) {
+		if y == year {
+			return nationwide(y, loc)
+		}
+		return r(y, loc)
+	}
+}
+
+// bussUndBettag is the rule of Buß- und Bettag, the Wednesday before
+// November 23.
+func bussUndBettag(y int, loc *time.Location) (time.Time, bool) {
+	d := time.Date(y, time.November, 22, 0, 0, 0, 0, loc)
+	back := (int(d.Weekday()) - int(time.Wednesday) + 7) % 7
+	return d.AddDate(0, 0, -back), true
+}
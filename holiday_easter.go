@@ -0,0 +1,80 @@
+package tart
+
+import (
+	"time"
+)
+
+// HolidaysEaster sets Easter, by the Gregorian computus, and the movable
+// feasts reckoned from it, along with Orthodox Easter, by the Julian computus.
+// The feasts are not marked as holidays, as which are kept as holidays
+// varies by place; see AsHoliday.
+func HolidaysEaster(t *Tart) error {
+	return t.SetBatch(
+		holidaysEaster(t),
+	)
+}
+
+func holidaysEaster(*Tart) map[string]Relation {
+	return map[string]Relation{
+		"ash-wednesday":   FromEaster(-46),
+		"palm-sunday":     FromEaster(-7),
+		"maundy-thursday": FromEaster(-3),
+		"good-friday":     FromEaster(-2),
+		"easter":          FromEaster(0),
+		"easter-monday":   FromEaster(1),
+		"ascension":       FromEaster(39),
+		"pentecost":       FromEaster(49),
+		"whit-monday":     FromEaster(50),
+		"corpus-christi":  FromEaster(60),
+		"orthodox-easter": FromOrthodoxEaster(0),
+	}
+}
+
+// FromEaster returns a Recurrent relation for the day the provided number of
+// days from Easter Sunday, by the Gregorian computus, e.g. -2 for Good Friday.
+func FromEaster(days int) Recurrent {
+	return fromRule(easter, days).relation()
+}
+
+// FromOrthodoxEaster returns a Recurrent relation for the day the provided
+// number of days from Orthodox Easter Sunday, by the Julian computus, given
+// as a Gregorian date.
+func FromOrthodoxEaster(days int) Recurrent {
+	return fromRule(orthodoxEaster, days).relation()
+}
+
+// fromRule is the rule of a holiday held the provided number of days from
+// the holiday of another rule.
+func fromRule(r dateRule, days int) dateRule {
+	return func(y int, loc *time.Location) (time.Time, bool) {
+		o, ok := r(y, loc)
+		return o.AddDate(0, 0, days), ok
+	}
+}
+
+// easter is the rule of Easter Sunday by the Gregorian computus, the
+// anonymous Gregorian algorithm.
+func easter(y int, loc *time.Location) (time.Time, bool) {
+	a := y % 19
+	b, c := y/100, y%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	n := h + l - 7*m + 114
+	return time.Date(y, time.Month(n/31), n%31+1, 0, 0, 0, 0, loc), true
+}
+
+// orthodoxEaster is the rule of Easter Sunday by the Julian computus,
+// Meeus's Julian algorithm, converted to the Gregorian calendar.
+func orthodoxEaster(y int, loc *time.Location) (time.Time, bool) {
+	a, b, c := y%4, y%7, y%19
+	d := (19*c + 15) % 30
+	e := (2*a + 4*b - d + 34) % 7
+	n := d + e + 114
+	julian := y/100 - y/400 - 2
+	return time.Date(y, time.Month(n/31), n%31+1+julian, 0, 0, 0, 0, loc), true
+}
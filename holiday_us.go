@@ -0,0 +1,44 @@
+package tart
+
+import (
+	"time"
+)
+
+// HolidaysUS sets the United States federal holidays, at 00:00:00 of the
+// day, but for "christmas", which is that of HolidaysBase. Holidays held on
+// a fixed date are set both as the date, e.g. "independence", and as the day
+// observed, e.g. "independence-observed", Saturday holidays observed the
+// Friday before and Sunday holidays the Monday after. The observed day is the
+// holiday business day arithmetic skips. Inauguration Day, held only in the
+// Washington D.C. area, is not set.
+func HolidaysUS(t *Tart) error {
+	return t.SetBatch(
+		holidaysUS(t),
+	)
+}
+
+func holidaysUS(t *Tart) map[string]Relation {
+	ret := map[string]Relation{
+		"mlk":          AsHoliday(nthWeekday(time.January, time.Monday, 3).relation()),
+		"washington":   AsHoliday(nthWeekday(time.February, time.Monday, 3).relation()),
+		"memorial":     AsHoliday(nthWeekday(time.May, time.Monday, -1).relation()),
+		"labor":        AsHoliday(nthWeekday(time.September, time.Monday, 1).relation()),
+		"columbus":     AsHoliday(nthWeekday(time.October, time.Monday, 2).relation()),
+		"thanksgiving": AsHoliday(nthWeekday(time.November, time.Thursday, 4).relation()),
+	}
+	fixed := map[string]dateRule{
+		"newyears":     fixedDate(time.January, 1),
+		"juneteenth":   since(2021, fixedDate(time.June, 19)),
+		"independence": fixedDate(time.July, 4),
+		"veterans":     fixedDate(time.November, 11),
+		"christmas":    fixedDate(time.December, 25),
+	}
+	for k, v := range fixed {
+		ret[k] = v.relation()
+		ret[k+"-observed"] = AsHoliday(observed(v).relation())
+	}
+	for k, v := range holidaysBase(t) {
+		ret[k] = v
+	}
+	return ret
+}
@@ -0,0 +1,271 @@
+package tart
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ImportICS returns a Config setting a relation for each event of the
+// iCalendar (RFC 5545) data read from the provided reader. Events are named
+// by their SUMMARY, lowercased with runs of spaces and punctuation as single
+// dashes, e.g. "All Hands" as "all-hands", and recur as given by their RRULE
+// less any EXDATE. Events sharing a name are one relation recurring over the
+// occurrences of all of them, so "!all-hands" is the next all hands and
+// "+!all-hands" the one after.
+//
+// An event named as a reserved key, e.g. "EOM" as "eom", is set as the name
+// with "-event" appended, "eom-event", and reported as an error to each of the
+// provided functions; where that name is also taken the event is skipped.
+//
+// Times without TZID or UTC designation are taken in the location of the
+// instance, as are dates. A TZID not in the IANA time zone database, whose
+// VTIMEZONE definitions are not read, is an error.
+func ImportICS(r io.Reader, report ...func(error)) Config {
+	return func(t *Tart) error {
+		evs, err := readICS(r, t.Location())
+		if err != nil {
+			return err
+		}
+		ret := make(map[string]Relation, len(evs))
+		for k, v := range evs {
+			if !isReservedKey(t.relations.rk, k) {
+				ret[k] = v
+				continue
+			}
+			rk := k + "-event"
+			err := fmt.Errorf("reading ics: '%s' is a reserved key, set as '%s'", k, rk)
+			if _, ok := evs[rk]; ok || isReservedKey(t.relations.rk, rk) {
+				err = fmt.Errorf("reading ics: '%s' is a reserved key, skipped", k)
+			} else {
+				ret[rk] = v
+			}
+			for _, fn := range report {
+				fn(err)
+			}
+		}
+		return t.SetBatch(ret)
+	}
+}
+
+type icsLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// readICSLines returns the unfolded content lines read from the reader.
+func readICSLines(r io.Reader) ([]icsLine, error) {
+	var raw []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(raw) > 0 {
+			raw[len(raw)-1] += l[1:]
+			continue
+		}
+		if l != "" {
+			raw = append(raw, l)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading ics: %s", err)
+	}
+	var ret []icsLine
+	for _, l := range raw {
+		i := strings.Index(l, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("reading ics: malformed line '%s'", l)
+		}
+		ps := strings.Split(l[:i], ";")
+		il := icsLine{strings.ToUpper(ps[0]), make(map[string]string), l[i+1:]}
+		for _, p := range ps[1:] {
+			if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+				il.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+			}
+		}
+		ret = append(ret, il)
+	}
+	return ret, nil
+}
+
+type icsEvent struct {
+	summary string
+	start   time.Time
+	rrule   string
+	exdates []time.Time
+}
+
+func (e icsEvent) schedule() (schedule, error) {
+	if e.rrule == "" {
+		return newDates(e.start), nil
+	}
+	r, err := parseRRule(e.rrule, e.start, false)
+	if err != nil {
+		return nil, fmt.Errorf("event '%s': %s", e.summary, err)
+	}
+	if len(e.exdates) > 0 {
+		return except{r, newDates(e.exdates...)}, nil
+	}
+	return r, nil
+}
+
+func readICS(r io.Reader, loc *time.Location) (map[string]Relation, error) {
+	ls, err := readICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+	var evs []icsEvent
+	var ev *icsEvent
+	for _, l := range ls {
+		switch {
+		case l.name == "BEGIN" && strings.EqualFold(l.value, "VEVENT"):
+			ev = &icsEvent{}
+		case l.name == "END" && strings.EqualFold(l.value, "VEVENT") && ev != nil:
+			if ev.summary == "" || ev.start.IsZero() {
+				return nil, fmt.Errorf("reading ics: event without SUMMARY or DTSTART")
+			}
+			evs = append(evs, *ev)
+			ev = nil
+		case ev == nil:
+		case l.name == "SUMMARY":
+			ev.summary = unescapeICS(l.value)
+		case l.name == "DTSTART":
+			if ev.start, err = parseICSTime(l.value, l.params, loc); err != nil {
+				return nil, fmt.Errorf("reading ics: invalid DTSTART '%s': %s", l.value, err)
+			}
+		case l.name == "RRULE":
+			ev.rrule = l.value
+		case l.name == "EXDATE":
+			for _, v := range strings.Split(l.value, ",") {
+				ex, err := parseICSTime(v, l.params, loc)
+				if err != nil {
+					return nil, fmt.Errorf("reading ics: invalid EXDATE '%s': %s", v, err)
+				}
+				ev.exdates = append(ev.exdates, ex)
+			}
+		}
+	}
+	scheds := make(map[string]union)
+	for _, e := range evs {
+		s, err := e.schedule()
+		if err != nil {
+			return nil, fmt.Errorf("reading ics: %s", err)
+		}
+		k := icsKey(e.summary)
+		scheds[k] = append(scheds[k], s)
+	}
+	ret := make(map[string]Relation)
+	for k, s := range scheds {
+		ret[k] = scheduleRelation(s)
+	}
+	return ret, nil
+}
+
+// parseICSTime parses an iCalendar DATE or DATE-TIME value, in the location of
+// the TZID parameter if any, UTC if designated, or the provided location.
+func parseICSTime(v string, params map[string]string, loc *time.Location) (time.Time, error) {
+	if tz, ok := params["TZID"]; ok {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID '%s'", tz)
+		}
+		loc = l
+	}
+	switch {
+	case strings.HasSuffix(v, "Z"):
+		return time.Parse("20060102T150405Z", v)
+	case len(v) == 8:
+		return time.ParseInLocation("20060102", v, loc)
+	}
+	return time.ParseInLocation("20060102T150405", v, loc)
+}
+
+var icsEscapes = strings.NewReplacer(`\\`, `\`, `\;`, `;`, `\,`, `,`, `\n`, "\n", `\N`, "\n")
+
+func unescapeICS(v string) string {
+	return icsEscapes.Replace(v)
+}
+
+var icsUnescapes = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\n", `\n`)
+
+func escapeICS(v string) string {
+	return icsUnescapes.Replace(v)
+}
+
+// icsKey returns the relation key of an event summary.
+func icsKey(summary string) string {
+	f := strings.FieldsFunc(strings.ToLower(summary), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(f, "-")
+}
+
+// ExportICS writes an iCalendar (RFC 5545) calendar to the provided writer, an
+// event for each of the provided directives at the time it evaluates to, with
+// the key as summary, e.g.
+//
+//	t.ExportICS(w, map[string]string{"Q3 report due": "<1w!eoq", "Launch": "!launch"})
+//
+// Events are written in order of summary.
+//
+// Content lines longer than 75 octets are folded.
+func (t *Tart) ExportICS(w io.Writer, events map[string]string) error {
+	ks := make([]string, 0, len(events))
+	for k := range events {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	stamp := t.clock.Now().UTC().Format("20060102T150405Z")
+	var b strings.Builder
+	writeICSLines(&b, "BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//tart//tart//EN")
+	for _, k := range ks {
+		tt, err := t.GetE(events[k])
+		if err != nil {
+			return err
+		}
+		start := tt.UTC().Format("20060102T150405Z")
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%s\x00%s", k, start)
+		writeICSLines(&b,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%x@tart", h.Sum64()),
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+start,
+			"SUMMARY:"+escapeICS(k),
+			"END:VEVENT",
+		)
+	}
+	writeICSLines(&b, "END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// maxICSLine is the length in octets content lines are folded at.
+const maxICSLine = 75
+
+// writeICSLines writes the provided content lines, each folded to lines of at
+// most maxICSLine octets, continued lines led by a space, without splitting a
+// UTF-8 sequence.
+func writeICSLines(b *strings.Builder, ls ...string) {
+	for _, l := range ls {
+		max := maxICSLine
+		for len(l) > max {
+			i := max
+			for i > 0 && !utf8.RuneStart(l[i]) {
+				i--
+			}
+			b.WriteString(l[:i])
+			b.WriteString("\r\n ")
+			l, max = l[i:], maxICSLine-1
+		}
+		b.WriteString(l)
+		b.WriteString("\r\n")
+	}
+}
@@ -0,0 +1,260 @@
+package tart
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Interval is the span of time from Start, inclusive, to End, exclusive.
+type Interval struct {
+	Start, End time.Time
+}
+
+// Contains reports whether the provided time falls within the interval.
+func (i Interval) Contains(t time.Time) bool {
+	return !t.Before(i.Start) && t.Before(i.End)
+}
+
+// Duration returns the length of the interval.
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// IsZero reports whether the interval is empty, ending at or before its start.
+func (i Interval) IsZero() bool {
+	return !i.End.After(i.Start)
+}
+
+// Overlaps reports whether the interval shares any time with the provided
+// interval.
+func (i Interval) Overlaps(o Interval) bool {
+	return i.Start.Before(o.End) && o.Start.Before(i.End)
+}
+
+func (i Interval) String() string {
+	return fmt.Sprintf("[%s, %s)", i.Start.Format(time.RFC3339), i.End.Format(time.RFC3339))
+}
+
+// Periodic is a Relation whose occurrences stand for periods of time, e.g. a
+// day or a quarter, rather than instants.
+type Periodic interface {
+	Relation
+	// Period returns the period of the occurrence at the provided time.
+	Period(time.Time) Interval
+}
+
+// PeriodFunc returns the period of the occurrence at the provided time.
+type PeriodFunc func(time.Time) Interval
+
+type periodic struct {
+	Recurrent
+	period PeriodFunc
+}
+
+func (p *periodic) Period(t time.Time) Interval {
+	return p.period(t)
+}
+
+// WithPeriod returns the provided Recurrent relation as Periodic, each
+// occurrence standing for the period given by the provided PeriodFunc.
+func WithPeriod(rc Recurrent, fn PeriodFunc) Recurrent {
+	return &periodic{rc, fn}
+}
+
+// period returns the period of the occurrence of the provided relation at the
+// provided time, the instant itself where the relation is not Periodic.
+func period(rl Relation, t time.Time) Interval {
+	if p, ok := rl.(Periodic); ok {
+		return p.Period(t)
+	}
+	return Interval{t, t}
+}
+
+// DayOf is the PeriodFunc of the day of the occurrence.
+func DayOf(t time.Time) Interval {
+	s := startOfDay(t)
+	return Interval{s, s.AddDate(0, 0, 1)}
+}
+
+// WeekOf is the PeriodFunc of the week of the occurrence, sunday to sunday.
+func WeekOf(t time.Time) Interval {
+	s := startOfDay(t).AddDate(0, 0, -int(t.Weekday()))
+	return Interval{s, s.AddDate(0, 0, 7)}
+}
+
+// WorkWeekOf is the PeriodFunc of the work week of the occurrence, monday to
+// saturday.
+func WorkWeekOf(t time.Time) Interval {
+	s := startOfDay(t).AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	return Interval{s, s.AddDate(0, 0, 5)}
+}
+
+// MonthOf is the PeriodFunc of the month of the occurrence.
+func MonthOf(t time.Time) Interval {
+	s := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return Interval{s, s.AddDate(0, 1, 0)}
+}
+
+// QuarterOf is the PeriodFunc of the quarter of the occurrence.
+func QuarterOf(t time.Time) Interval {
+	s := time.Date(t.Year(), (t.Month()-1)/3*3+1, 1, 0, 0, 0, 0, t.Location())
+	return Interval{s, s.AddDate(0, 3, 0)}
+}
+
+// YearOf is the PeriodFunc of the year of the occurrence.
+func YearOf(t time.Time) Interval {
+	s := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	return Interval{s, s.AddDate(1, 0, 0)}
+}
+
+// Merge returns the provided intervals in order of start, overlapping and
+// abutting intervals merged and empty intervals dropped.
+func Merge(is []Interval) []Interval {
+	s := make([]Interval, 0, len(is))
+	for _, v := range is {
+		if !v.IsZero() {
+			s = append(s, v)
+		}
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Start.Before(s[j].Start) })
+	var ret []Interval
+	for _, v := range s {
+		if n := len(ret); n > 0 && !v.Start.After(ret[n-1].End) {
+			if v.End.After(ret[n-1].End) {
+				ret[n-1].End = v.End
+			}
+			continue
+		}
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+// Intersect returns the time within both the provided interval lists, merged.
+func Intersect(a, b []Interval) []Interval {
+	a, b = Merge(a), Merge(b)
+	var ret []Interval
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		s, e := later(a[i].Start, b[j].Start), earlier(a[i].End, b[j].End)
+		if s.Before(e) {
+			ret = append(ret, Interval{s, e})
+		}
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return ret
+}
+
+// Subtract returns the time within the first interval list and not the
+// second, merged.
+func Subtract(a, b []Interval) []Interval {
+	var ret []Interval
+	for _, v := range Merge(a) {
+		ret = append(ret, Complement(b, v)...)
+	}
+	return ret
+}
+
+// Complement returns the time within the provided window not within the
+// provided intervals, merged.
+func Complement(is []Interval, within Interval) []Interval {
+	var ret []Interval
+	at := within.Start
+	for _, v := range Merge(is) {
+		if !v.End.After(at) {
+			continue
+		}
+		if !v.Start.Before(within.End) {
+			break
+		}
+		if v.Start.After(at) {
+			ret = append(ret, Interval{at, v.Start})
+		}
+		at = v.End
+	}
+	if at.Before(within.End) {
+		ret = append(ret, Interval{at, within.End})
+	}
+	return ret
+}
+
+// Gaps returns the free time within the provided window between the provided
+// busy intervals, each gap at least the provided duration, e.g. the slots
+// open for a meeting of an hour:
+//
+//	busy := []tart.Interval{t.GetInterval("!lunch"), t.GetInterval("!standup")}
+//	slots := tart.Gaps(busy, t.GetInterval("!tomorrow"), time.Hour)
+func Gaps(busy []Interval, within Interval, min time.Duration) []Interval {
+	var ret []Interval
+	for _, v := range Complement(busy, within) {
+		if v.Duration() >= min {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// GetInterval returns the interval of the provided directive: the period of
+// its point, shifted as the directive. The occurrence of the point is found
+// as Get finds it, from which the relation of the point gives its natural
+// period, e.g.
+//
+//	"!today"        = [today 00:00, tomorrow 00:00)
+//	"!q3"           = [july 1, october 1)
+//	"!eom"          = the current month, the month it ends
+//	"+!monday"      = the whole of the monday after next
+//	">1w!christmas" = the day a week after christmas
+//
+// Days, weekdays and holidays are days; som, socm, eom, eocm and month names
+// months; soq, eoq, q1 through q4 and quarter:1 through quarter:4 quarters;
+// soy and eoy years; sow, socw, eow and eocw weeks from sunday; soww and eoww
+// work weeks, monday to saturday. Relations without a period, e.g. "now",
+// dates, or those set with Set, are the empty interval at their time.
+//
+// GetInterval never fails, as Get. Use GetIntervalE to be told about a
+// malformed directive.
+func (t *Tart) GetInterval(in string) Interval {
+	ret, _ := t.GetIntervalE(in)
+	return ret
+}
+
+// GetIntervalE is GetInterval, returning a *ParseError as GetE.
+func (t *Tart) GetIntervalE(in string) (Interval, error) {
+	d := t.directive(in)
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	c := &Context{Time: t.Time, Tart: t, Directive: d}
+	rl := t.relation(d.phrase)
+	oc := &Context{Time: t.Time, Tart: t, Directive: d.unshifted()}
+	o := relative(rl, oc)()
+	p := period(rl, o)
+	ret := Interval{c.Shift(p.Start), c.Shift(p.End)}
+	if d.err != nil {
+		return ret, d.err
+	}
+	if oc.err != nil {
+		return ret, oc.err
+	}
+	if c.err != nil {
+		return ret, c.err
+	}
+	return ret, t.checkPoint(c)
+}
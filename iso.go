@@ -0,0 +1,251 @@
+package tart
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var isoLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"20060102T150405Z0700",
+	"20060102T150405Z",
+	"20060102T150405",
+	"20060102",
+}
+
+// parseISOTime parses an ISO 8601 date or date and time, in the provided
+// location where without offset.
+func parseISOTime(in string, loc *time.Location) (time.Time, bool) {
+	for _, l := range isoLayouts {
+		if t, err := time.ParseInLocation(l, in, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func isoIntervalError(in, msg string, a ...interface{}) error {
+	return fmt.Errorf("interval '%s': %s", in, fmt.Sprintf(msg, a...))
+}
+
+// isoSide is a side of an ISO 8601 interval, a time or a period.
+type isoSide struct {
+	t      time.Time
+	p      Period
+	period bool
+}
+
+// side resolves a side of an interval: a period, an ISO 8601 time, the
+// instance time where open, ".." or empty, or else the time of a directive.
+func (t *Tart) side(in string) (isoSide, error) {
+	if strings.HasPrefix(in, "P") || strings.HasPrefix(in, "-P") {
+		p, err := ParsePeriod(in)
+		return isoSide{p: p, period: true}, err
+	}
+	t.mu.RLock()
+	now := t.Time
+	t.mu.RUnlock()
+	if in == "" || in == ".." {
+		return isoSide{t: now}, nil
+	}
+	if tt, ok := parseISOTime(in, now.Location()); ok {
+		return isoSide{t: tt}, nil
+	}
+	tt, err := t.GetE(in)
+	return isoSide{t: tt}, err
+}
+
+// isoPlain reports whether the provided side of an interval is an ISO 8601
+// time or period, or open, rather than a directive.
+func isoPlain(in string) bool {
+	if in == "" || in == ".." || strings.HasPrefix(in, "P") || strings.HasPrefix(in, "-P") {
+		return true
+	}
+	_, ok := parseISOTime(in, time.UTC)
+	return ok
+}
+
+// separators returns the indexes of each '/' of the provided interval outside
+// double quotes.
+func separators(in string) []int {
+	var ret []int
+	var quoted, escaped bool
+	for i := 0; i < len(in); i++ {
+		switch c := in[i]; {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == '/' && !quoted:
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// sides resolves the two sides of the provided interval, split at the '/'
+// whose sides both resolve with the most ISO 8601 sides, the first such.
+func (t *Tart) sides(in string) (isoSide, isoSide, error) {
+	var a, b isoSide
+	var first error
+	best := -1
+	for _, i := range separators(in) {
+		l, r := in[:i], in[i+1:]
+		sa, err := t.side(l)
+		if err == nil {
+			var sb isoSide
+			if sb, err = t.side(r); err == nil {
+				var n int
+				for _, s := range []string{l, r} {
+					if isoPlain(s) {
+						n++
+					}
+				}
+				if n > best {
+					a, b, best = sa, sb, n
+				}
+				continue
+			}
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	switch {
+	case best >= 0:
+		return a, b, nil
+	case first != nil:
+		return a, b, isoIntervalError(in, "%s", first)
+	}
+	return a, b, isoIntervalError(in, "expected two sides separated by '/'")
+}
+
+// ParseInterval parses an ISO 8601 interval, of the forms "start/end",
+// "start/period" and "period/end", e.g.
+//
+//	"2019-07-04T00:00Z/P1W"
+//	"2019-07-04/2019-07-06"
+//	"P1D/2019-07-04T17:00-04:00"
+//
+// Times are ISO 8601 dates or dates and times, in the location of the
+// instance where without offset. A side that is neither is open and resolved
+// against the instance: ".." or nothing is the instance time, and anything
+// else a directive, e.g. "!now/P1W", "P1D/!eod" or "!monday/!friday".
+//
+// A directive may hold a '/' of its own, e.g. "!cron:*/15 * * * */PT1H": the
+// interval is split at the '/' whose sides both resolve, preferring sides
+// that are ISO 8601 times, periods or open, and never at a '/' within a
+// quoted point, e.g. `!"7/4/2019"/!"7/6/2019"`.
+func (t *Tart) ParseInterval(in string) (Interval, error) {
+	ret, _, err := t.parseInterval(in)
+	return ret, err
+}
+
+// parseInterval returns the interval and the period of its length, the
+// period given or else the calendar distance from start to end.
+func (t *Tart) parseInterval(in string) (Interval, Period, error) {
+	a, b, err := t.sides(in)
+	if err != nil {
+		return Interval{}, Period{}, err
+	}
+	switch {
+	case a.period && b.period:
+		return Interval{}, Period{}, isoIntervalError(in, "expected a time on at least one side")
+	case a.period:
+		return Interval{a.p.Negate().AddTo(b.t), b.t}, a.p, nil
+	case b.period:
+		return Interval{a.t, b.p.AddTo(a.t)}, b.p, nil
+	}
+	if b.t.Before(a.t) {
+		return Interval{}, Period{}, isoIntervalError(in, "ends before it starts")
+	}
+	return Interval{a.t, b.t}, distance(a.t, b.t).Period, nil
+}
+
+// Repeating is an ISO 8601 repeating interval, the first interval repeated
+// every period, a number of times or without end.
+type Repeating struct {
+	First Interval
+	Every Period
+	// Count is the number of intervals, the first included, or -1 for
+	// intervals without end.
+	Count int
+	// Backward repeats the interval back from the first, as of intervals
+	// given by period and end.
+	Backward bool
+}
+
+// times returns the period multiplied by n.
+func (p Period) times(n int) Period {
+	return Period{n * p.Years, n * p.Months, n * p.Days, time.Duration(n) * p.Duration}
+}
+
+// Intervals returns the intervals of the repeating interval in order of
+// repetition, later and later, or earlier and earlier where Backward.
+func (r Repeating) Intervals() iter.Seq[Interval] {
+	return func(yield func(Interval) bool) {
+		for k := 0; r.Count < 0 || k < r.Count; k++ {
+			n := k
+			if r.Backward {
+				n = -k
+			}
+			p := r.Every.times(n)
+			if !yield(Interval{p.AddTo(r.First.Start), p.AddTo(r.First.End)}) {
+				return
+			}
+		}
+	}
+}
+
+// Occurrences returns the start of each of the intervals of the repeating
+// interval, in the order of Intervals.
+func (r Repeating) Occurrences() iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		for i := range r.Intervals() {
+			if !yield(i.Start) {
+				return
+			}
+		}
+	}
+}
+
+// ParseRepeating parses an ISO 8601 repeating interval, "Rn/" followed by
+// an interval as ParseInterval, for n intervals, or "R/" for intervals
+// without end, e.g.
+//
+//	"R5/2019-07-04T09:00Z/P1W"  = five weekly intervals, a week long
+//	"R/!monday/P1W"             = the weeks from next monday, without end
+//
+// An interval of start and end repeats every calendar distance from start to
+// end, and one of period and end repeats backward from the end.
+func (t *Tart) ParseRepeating(in string) (Repeating, error) {
+	i := strings.Index(in, "/")
+	if !strings.HasPrefix(in, "R") || i < 0 {
+		return Repeating{}, isoIntervalError(in, "expected 'R' and repetitions before '/'")
+	}
+	count := -1
+	if n := in[1:i]; n != "" {
+		var err error
+		if count, err = strconv.Atoi(n); err != nil || count < 0 {
+			return Repeating{}, isoIntervalError(in, "invalid repetitions '%s'", n)
+		}
+	}
+	first, every, err := t.parseInterval(in[i+1:])
+	if err != nil {
+		return Repeating{}, err
+	}
+	if every.IsZero() {
+		return Repeating{}, isoIntervalError(in, "repeats every period of no length")
+	}
+	backward := strings.HasPrefix(in[i+1:], "P") || strings.HasPrefix(in[i+1:], "-P")
+	return Repeating{first, every, count, backward}, nil
+}
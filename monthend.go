@@ -0,0 +1,42 @@
+package tart
+
+import (
+	"time"
+)
+
+// MonthEnd is the policy for shifts by months and years landing on a day
+// past the end of the month, e.g. a month on from january 31.
+type MonthEnd int
+
+const (
+	// MonthOverflow carries the days past the end of the month into the
+	// next, as time.AddDate: a month on from january 31 is march 3 (or 2).
+	MonthOverflow MonthEnd = iota + 1
+	// MonthClamp stops at the last day of the month: a month on from january
+	// 31 is february 28 (or 29).
+	MonthClamp
+	// MonthPreserveEOM clamps, and keeps the last day of a month the last
+	// day: a month on from february 28 is march 31.
+	MonthPreserveEOM
+)
+
+// monthEndOptions are the directive options choosing a MonthEnd policy, e.g.
+// "@clamp>1mo!".
+var monthEndOptions = map[string]MonthEnd{
+	"overflow": MonthOverflow,
+	"clamp":    MonthClamp,
+	"eom":      MonthPreserveEOM,
+}
+
+// WithMonthEnd sets the MonthEnd policy of shifts by months and years for
+// directives not choosing their own, MonthOverflow by default.
+func WithMonthEnd(m MonthEnd) Config {
+	return func(t *Tart) error {
+		t.monthEnd = m
+		return nil
+	}
+}
+
+func lastDay(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,90 @@
+package tart
+
+import (
+	"iter"
+	"time"
+)
+
+// Occurrences returns the successive times of the provided directive from
+// the provided time, inclusive, to the provided time, exclusive, e.g.
+//
+//	for o := range t.Occurrences(">9h!monday", from, to) {
+//		...
+//	}
+//
+// are the mondays at 09:00 between from and to. The occurrences of a recurrent
+// point, each shifted as the directive, are walked in order; the iters and
+// selector of the directive choose among these same occurrences and so are
+// without effect. A point that does not recur has the single time of the
+// directive evaluated at from, where it falls between from and to.
+//
+// The relations of the instance are used as they are set at each step of the
+// iteration, and the instance time is neither used nor changed. A malformed
+// directive has no occurrences; use OccurrencesE to be told about it.
+func (t *Tart) Occurrences(in string, from, to time.Time) iter.Seq[time.Time] {
+	seq, _ := t.OccurrencesE(in, from, to)
+	return seq
+}
+
+// OccurrencesE is Occurrences, returning a *ParseError as GetE for a
+// malformed directive, with an empty sequence.
+func (t *Tart) OccurrencesE(in string, from, to time.Time) (iter.Seq[time.Time], error) {
+	d := t.directive(in)
+	if d.err != nil {
+		return func(func(time.Time) bool) {}, d.err
+	}
+	return func(yield func(time.Time) bool) {
+		c := &Context{Time: from, Tart: t, Directive: d}
+		t.mu.RLock()
+		o, rc, ok := t.firstOccurrence(c)
+		t.mu.RUnlock()
+		if !ok {
+			if c.err == nil && !o.Before(from) && o.Before(to) {
+				yield(o)
+			}
+			return
+		}
+		if o.IsZero() {
+			return
+		}
+		for {
+			t.mu.RLock()
+			v, n := c.Shift(o), rc.Step(o, 1)
+			t.mu.RUnlock()
+			if !v.Before(to) {
+				return
+			}
+			if !v.Before(from) && !yield(v) {
+				return
+			}
+			if !n.After(o) {
+				return
+			}
+			o = n
+		}
+	}, nil
+}
+
+// firstOccurrence returns the first occurrence of the point of the directive
+// of the provided Context, unshifted, and its Recurrent relation, or the time
+// of a point that does not recur.
+func (t *Tart) firstOccurrence(c *Context) (time.Time, Recurrent, bool) {
+	d, from := c.Directive, c.Time
+	rl := t.relation(d.phrase)
+	rc, ok := rl.(Recurrent)
+	if !ok {
+		return rl.Relative(c)(), nil, false
+	}
+	o := rl.Relative(&Context{Time: from, Tart: t, Directive: d.bare()})()
+	if o.IsZero() {
+		return o, rc, true
+	}
+	for {
+		p := rc.Step(o, -1)
+		if !p.Before(o) || c.Shift(p).Before(from) {
+			break
+		}
+		o = p
+	}
+	return o, rc, true
+}
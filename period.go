@@ -0,0 +1,208 @@
+package tart
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is a calendar period: years, months and days, which vary in length
+// with the calendar, and a clock duration.
+type Period struct {
+	Years, Months, Days int
+	Duration            time.Duration
+}
+
+// Add returns the sum of the period and the provided period.
+func (p Period) Add(o Period) Period {
+	return Period{p.Years + o.Years, p.Months + o.Months, p.Days + o.Days, p.Duration + o.Duration}
+}
+
+// Negate returns the period with each of its components negated.
+func (p Period) Negate() Period {
+	return Period{-p.Years, -p.Months, -p.Days, -p.Duration}
+}
+
+// Normalize returns the period with months carried into years and whole 24
+// hours of the duration carried into days, e.g. 14 months and 30 hours as 1
+// year, 2 months, 1 day and 6 hours.
+func (p Period) Normalize() Period {
+	months := p.Years*12 + p.Months
+	days := p.Duration / (24 * time.Hour)
+	return Period{months / 12, months % 12, p.Days + int(days), p.Duration - days*24*time.Hour}
+}
+
+// AddTo returns the provided time moved by the period, the duration first,
+// then years, months and days as time.AddDate adds them, so a month and an
+// hour from january 31 23:30 is march 1 00:30. This is the order directive
+// shifts have always had, where ISO 8601 adds the date first.
+func (p Period) AddTo(t time.Time) time.Time {
+	return t.Add(p.Duration).AddDate(p.Years, p.Months, p.Days)
+}
+
+// IsZero reports whether the period is of no length.
+func (p Period) IsZero() bool {
+	return p == Period{}
+}
+
+// String returns the period in ISO 8601 form, e.g. "P1Y2M3DT4H5M6.5S", "PT0S"
+// for the zero period. A period with no positive component is given with a
+// leading '-', e.g. "-P1D". A period of mixed signs has no ISO 8601 form and
+// is given with its negative components negative, e.g. "P1M-1D" or
+// "P1DT-1H-30M", as ParsePeriod reads them.
+func (p Period) String() string {
+	if p.IsZero() {
+		return "PT0S"
+	}
+	var b strings.Builder
+	if p.Years <= 0 && p.Months <= 0 && p.Days <= 0 && p.Duration <= 0 {
+		b.WriteByte('-')
+		p = p.Negate()
+	}
+	b.WriteByte('P')
+	for _, v := range []struct {
+		n    int
+		unit byte
+	}{{p.Years, 'Y'}, {p.Months, 'M'}, {p.Days, 'D'}} {
+		if v.n != 0 {
+			fmt.Fprintf(&b, "%d%c", v.n, v.unit)
+		}
+	}
+	if p.Duration != 0 {
+		b.WriteByte('T')
+		h := p.Duration / time.Hour
+		m := (p.Duration - h*time.Hour) / time.Minute
+		s := p.Duration - h*time.Hour - m*time.Minute
+		if h != 0 {
+			fmt.Fprintf(&b, "%dH", h)
+		}
+		if m != 0 {
+			fmt.Fprintf(&b, "%dM", m)
+		}
+		if s != 0 {
+			b.WriteString(strconv.FormatFloat(s.Seconds(), 'f', -1, 64))
+			b.WriteByte('S')
+		}
+	}
+	return b.String()
+}
+
+// overflows reports whether the sum of a and b overflows an int.
+func overflows(a, b int) bool {
+	return (b > 0 && a > math.MaxInt-b) || (b < 0 && a < math.MinInt-b)
+}
+
+func periodError(in, msg string) error {
+	return fmt.Errorf("period '%s': %s", in, msg)
+}
+
+// ParsePeriod parses an ISO 8601 duration, e.g. "P1Y2M3DT4H5M6S", "P2W" or
+// "-PT1.5H", of years, months, weeks and days, then 'T' and hours, minutes
+// and seconds, each at most once and in that order, the last given of which
+// may be fractional. Beyond ISO 8601, components may be negative, e.g.
+// "P1M-1D", as String gives periods of mixed signs. Components too large for
+// an int, or a duration too long for a time.Duration, are an error.
+func ParsePeriod(in string) (Period, error) {
+	var p Period
+	s := in
+	neg := strings.HasPrefix(s, "-")
+	if neg || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) == 1 {
+		return p, periodError(in, "expected P followed by components")
+	}
+	s = s[1:]
+	var clock, any, fraction bool
+	units, last := "YMWD", -1
+	for s != "" {
+		if s[0] == 'T' {
+			if clock || len(s) == 1 {
+				return p, periodError(in, "misplaced T")
+			}
+			clock, s = true, s[1:]
+			units, last = "HMS", -1
+			continue
+		}
+		var sign string
+		if s[0] == '-' {
+			sign, s = "-", s[1:]
+		}
+		i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != ',' })
+		if i <= 0 {
+			return p, periodError(in, "expected a number before each unit")
+		}
+		if fraction {
+			return p, periodError(in, "only the last component may be fractional")
+		}
+		num, unit := sign+strings.Replace(s[:i], ",", ".", 1), s[i]
+		s = s[i+1:]
+		if u := strings.IndexByte(units, unit); u >= 0 {
+			if u <= last {
+				return p, periodError(in, fmt.Sprintf("unit '%c' repeated or out of order", unit))
+			}
+			last = u
+		}
+		if strings.Contains(num, ".") {
+			fraction = true
+		}
+		any = true
+		if clock {
+			f, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return p, periodError(in, fmt.Sprintf("invalid number '%s'", num))
+			}
+			var u time.Duration
+			switch unit {
+			case 'H':
+				u = time.Hour
+			case 'M':
+				u = time.Minute
+			case 'S':
+				u = time.Second
+			default:
+				return p, periodError(in, fmt.Sprintf("unknown time unit '%c'", unit))
+			}
+			if math.Abs(float64(p.Duration)+f*float64(u)) >= math.MaxInt64 {
+				return p, periodError(in, fmt.Sprintf("number '%s' overflows", num))
+			}
+			p.Duration += time.Duration(f * float64(u))
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if errors.Is(err, strconv.ErrRange) {
+			return p, periodError(in, fmt.Sprintf("number '%s' overflows", num))
+		}
+		if err != nil {
+			return p, periodError(in, fmt.Sprintf("invalid number '%s', fractions only of hours, minutes and seconds", num))
+		}
+		switch unit {
+		case 'Y':
+			p.Years += n
+		case 'M':
+			p.Months += n
+		case 'W':
+			if n > math.MaxInt/7 || n < math.MinInt/7 || overflows(p.Days, 7*n) {
+				return p, periodError(in, fmt.Sprintf("number '%s' overflows", num))
+			}
+			p.Days += 7 * n
+		case 'D':
+			if overflows(p.Days, n) {
+				return p, periodError(in, fmt.Sprintf("number '%s' overflows", num))
+			}
+			p.Days += n
+		default:
+			return p, periodError(in, fmt.Sprintf("unknown date unit '%c'", unit))
+		}
+	}
+	if !any {
+		return p, periodError(in, "expected P followed by components")
+	}
+	if neg {
+		p = p.Negate()
+	}
+	return p, nil
+}
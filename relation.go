@@ -3,7 +3,9 @@ package tart
 import (
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/araddon/dateparse"
@@ -11,14 +13,41 @@ import (
 
 type (
 	// RelativeFunc ...
-	RelativeFunc func(*Tart) TimeFunc
+	RelativeFunc func(*Context) TimeFunc
 	// TimeFunc ...
 	TimeFunc func() time.Time
 )
 
+// Context is the context a directive is evaluated in: the anchor time, the
+// Tart instance evaluating and the directive evaluated. The embedded time is
+// the anchor, the time of the Tart instance at evaluation.
+type Context struct {
+	time.Time
+	Tart      *Tart
+	Directive *Directive
+	err       error
+}
+
+// fail records the first error evaluating the Context.
+func (c *Context) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// noOccurrenceError returns the error of a relation without an occurrence
+// from the anchor of the provided Context.
+func noOccurrenceError(c *Context) error {
+	var origin, phrase string
+	if d := c.Directive; d != nil {
+		origin, phrase = d.origin, d.phrase
+	}
+	return fmt.Errorf("%q: no occurrence of %q from %s", origin, phrase, c.Time.Format(time.RFC3339))
+}
+
 // Relation ...
 type Relation interface {
-	Relative(*Tart) TimeFunc
+	Relative(*Context) TimeFunc
 }
 
 type relation struct {
@@ -29,12 +58,122 @@ func newRelation(rfn RelativeFunc) *relation {
 	return &relation{rfn}
 }
 
-func (r *relation) Relative(t *Tart) TimeFunc {
+func (r *relation) Relative(t *Context) TimeFunc {
 	return r.rfn(t)
 }
 
-// relations is a struct managing core time relations for a Tart instance.
+// StepFunc returns the occurrence n occurrences on from the provided
+// occurrence, forward for positive n and backward for negative n.
+type StepFunc func(time.Time, int) time.Time
+
+// Recurrent is a Relation with a natural recurrence, e.g. weekly for a
+// weekday, that iter modifiers step through.
+type Recurrent interface {
+	Relation
+	Step(time.Time, int) time.Time
+}
+
+type recurrent struct {
+	Relation
+	step StepFunc
+}
+
+func newRecurrent(rl Relation, step StepFunc) *recurrent {
+	return &recurrent{rl, step}
+}
+
+func (r *recurrent) Step(t time.Time, n int) time.Time {
+	return r.step(t, n)
+}
+
+// Recurring returns a Recurrent relation from the provided RelativeFunc,
+// recurring by the provided StepFunc.
+func Recurring(rfn RelativeFunc, step StepFunc) Recurrent {
+	return newRecurrent(newRelation(rfn), step)
+}
+
+// Every returns a StepFunc stepping by the provided years, months and days.
+func Every(years, months, days int) StepFunc {
+	return func(t time.Time, n int) time.Time {
+		return t.AddDate(n*years, n*months, n*days)
+	}
+}
+
+// previous returns the latest occurrence of rc before the anchor, found by
+// stepping from the provided occurrence.
+func previous(rc Recurrent, o, anchor time.Time) time.Time {
+	for !o.Before(anchor) {
+		p := rc.Step(o, -1)
+		if !p.Before(o) {
+			return o
+		}
+		o = p
+	}
+	for nx := rc.Step(o, 1); nx.Before(anchor) && nx.After(o); nx = rc.Step(o, 1) {
+		o = nx
+	}
+	return o
+}
+
+// following returns the earliest occurrence of rc not before the anchor,
+// found by stepping from the provided occurrence.
+func following(rc Recurrent, o, anchor time.Time) time.Time {
+	for o.Before(anchor) {
+		nx := rc.Step(o, 1)
+		if !nx.After(o) {
+			return o
+		}
+		o = nx
+	}
+	for p := rc.Step(o, -1); !p.Before(anchor) && p.Before(o); p = rc.Step(o, -1) {
+		o = p
+	}
+	return o
+}
+
+// nearest returns the occurrence of rc nearest the anchor, the later of the
+// two when equally near.
+func nearest(rc Recurrent, o, anchor time.Time) time.Time {
+	p, f := previous(rc, o, anchor), following(rc, o, anchor)
+	if anchor.Sub(p) < f.Sub(anchor) {
+		return p
+	}
+	return f
+}
+
+// stepped reports whether each of the n occurrences of rc stepped through from
+// the provided occurrence exists, each step moving the way it steps.
+func stepped(rc Recurrent, o time.Time, n int) bool {
+	for ; n > 0; n-- {
+		nx := rc.Step(o, 1)
+		if !nx.After(o) {
+			return false
+		}
+		o = nx
+	}
+	for ; n < 0; n++ {
+		p := rc.Step(o, -1)
+		if !p.Before(o) {
+			return false
+		}
+		o = p
+	}
+	return true
+}
+
+// everyEOM returns a StepFunc stepping from the last day of a month to the
+// last day of the month the provided number of months on, keeping the clock.
+func everyEOM(months int) StepFunc {
+	return func(t time.Time, n int) time.Time {
+		f := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		return f.AddDate(0, n*months+1, -1)
+	}
+}
+
+// relations is a struct managing core time relations for a Tart instance,
+// safe for concurrent use.
 type relations struct {
+	mu             sync.RWMutex
 	t              *Tart
 	storedRelation map[string]Relation
 	storedTfn      map[string]TimeFunc
@@ -48,38 +187,65 @@ func newRelations(t *Tart) *relations {
 }
 
 func (r *relations) reset(t *Tart) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.t = t
 	r.storedRelation, r.rk = defaultRelativeFuncs(r.t)
 	r.storedTfn = make(map[string]TimeFunc)
 }
 
+// flush removes all cached TimeFuncs.
+func (r *relations) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.storedTfn = make(map[string]TimeFunc)
+}
+
+// clone returns a copy of the relations for the provided Tart instance,
+// without cached TimeFuncs.
+func (r *relations) clone(t *Tart) *relations {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n := &relations{
+		t:              t,
+		storedRelation: make(map[string]Relation, len(r.storedRelation)),
+		storedTfn:      make(map[string]TimeFunc),
+		rk:             r.rk,
+	}
+	for k, v := range r.storedRelation {
+		n.storedRelation[k] = v
+	}
+	return n
+}
+
 func defaultRelativeFuncs(t *Tart) (map[string]Relation, []string) {
+	daily, weekly, monthly, yearly := Every(0, 0, 1), Every(0, 0, 7), Every(0, 1, 0), Every(1, 0, 0)
 	r := map[string]Relation{
 		"any":       newRelation(Any),
 		"default":   newRelation(Any),
-		"eocm":      newRelation(EOM),
-		"eocw":      newRelation(EOW),
-		"eod":       newRelation(EOD),
-		"eom":       newRelation(EOM),
-		"eoq":       newRelation(EOQ),
-		"eow":       newRelation(EOW),
-		"eoww":      newRelation(EOWW),
-		"eoy":       newRelation(EOY),
+		"eocm":      WithPeriod(Recurring(EOM, everyEOM(1)), MonthOf),
+		"eocw":      WithPeriod(Recurring(EOW, weekly), WeekOf),
+		"eod":       WithPeriod(Recurring(EOD, daily), DayOf),
+		"eom":       WithPeriod(Recurring(EOM, everyEOM(1)), MonthOf),
+		"eoq":       WithPeriod(Recurring(EOQ, everyEOM(3)), QuarterOf),
+		"eow":       WithPeriod(Recurring(EOW, weekly), WeekOf),
+		"eoww":      WithPeriod(Recurring(EOWW, weekly), WorkWeekOf),
+		"eoy":       WithPeriod(Recurring(EOY, yearly), YearOf),
 		"later":     newRelation(Whenever),
 		"now":       newRelation(Now),
-		"socm":      newRelation(SOCM),
-		"socw":      newRelation(SOCW),
-		"sod":       newRelation(Tomorrow),
-		"som":       newRelation(SOM),
+		"socm":      WithPeriod(Recurring(SOCM, monthly), MonthOf),
+		"socw":      WithPeriod(Recurring(SOCW, weekly), WeekOf),
+		"sod":       WithPeriod(Recurring(Tomorrow, daily), DayOf),
+		"som":       WithPeriod(Recurring(SOM, monthly), MonthOf),
 		"someday":   newRelation(Whenever),
-		"soq":       newRelation(SOQ),
-		"sow":       newRelation(SOW),
-		"soww":      newRelation(SOWW),
-		"soy":       newRelation(SOY),
-		"today":     newRelation(Today),
-		"tomorrow":  newRelation(Tomorrow),
+		"soq":       WithPeriod(Recurring(SOQ, Every(0, 3, 0)), QuarterOf),
+		"sow":       WithPeriod(Recurring(SOW, weekly), WeekOf),
+		"soww":      WithPeriod(Recurring(SOWW, weekly), WorkWeekOf),
+		"soy":       WithPeriod(Recurring(SOY, yearly), YearOf),
+		"today":     WithPeriod(Recurring(Today, daily), DayOf),
+		"tomorrow":  WithPeriod(Recurring(Tomorrow, daily), DayOf),
 		"whenever":  newRelation(Whenever),
-		"yesterday": newRelation(Yesterday),
+		"yesterday": WithPeriod(Recurring(Yesterday, daily), DayOf),
 	}
 	for _, d := range daysOfWeek() {
 		r[d] = NominalDay(t, d)
@@ -108,33 +274,88 @@ func monthsOfYear() []string {
 	}
 }
 
-// Return the TimeFunc of the provided string, relative to the Tart instance time.
-func (r *relations) popTimeFn() TimeFunc {
-	t := r.t
-	d := t.last
+// Return the TimeFunc of the directive of the provided Context, relative to
+// the anchor of the Context.
+func (r *relations) popTimeFn(c *Context) TimeFunc {
+	d := c.Directive
 
-	if etfn, ok := r.storedTfn[d.origin]; ok {
+	r.mu.RLock()
+	etfn, ok := r.storedTfn[d.origin]
+	r.mu.RUnlock()
+	if ok {
 		return etfn
 	}
 
-	var rfn RelativeFunc
-
-	rl := r.GetRelation(d.phrase)
-	if rl != nil {
-		rfn = rl.Relative
-	} else {
-		rfn = r.storedRelation["default"].Relative
+	tfn := relative(r.relation(d.phrase), c)
+	if c.err != nil {
+		return tfn
 	}
 
-	tfn := rfn(t)
-
+	r.mu.Lock()
 	r.storedTfn[d.origin] = tfn
+	r.mu.Unlock()
 
 	return tfn
 }
 
+// relation returns the relation of the provided point phrase: the stored
+// relation, the schedule of a prefixed point, or the default relation.
+func (r *relations) relation(phrase string) Relation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if rl := r.storedRelation[phrase]; rl != nil {
+		return rl
+	}
+	if prl, ok, err := prefixRelation(phrase); ok && err == nil {
+		return prl
+	}
+	if qrl := quarterRelation(phrase); qrl != nil {
+		return qrl
+	}
+	return r.storedRelation["default"]
+}
+
+// relative returns the TimeFunc of the provided relation in the provided
+// Context. For a Recurrent relation the occurrence selected by the directive
+// is found, then stepped through by the iters of the directive, before
+// shifting. Selecting or stepping to an occurrence that does not exist fails
+// the Context.
+func relative(rl Relation, c *Context) TimeFunc {
+	d := c.Directive
+	rc, ok := rl.(Recurrent)
+	n := d.steps()
+	if !ok || (n == 0 && d.sel == selNext) {
+		return rl.Relative(c)
+	}
+	bc := &Context{Time: c.Time, Tart: c.Tart, Directive: d.bare()}
+	base := rl.Relative(bc)()
+	if bc.err != nil {
+		c.fail(bc.err)
+		return func() time.Time {
+			return base
+		}
+	}
+	switch d.sel {
+	case selLast:
+		if base = previous(rc, base, c.Time); !base.Before(c.Time) {
+			c.fail(noOccurrenceError(c))
+		}
+	case selNearest:
+		base = nearest(rc, base, c.Time)
+	}
+	if !stepped(rc, base, n) {
+		c.fail(noOccurrenceError(c))
+	}
+	nt := c.Shift(rc.Step(base, n))
+	return func() time.Time {
+		return nt
+	}
+}
+
 // GetRelation ...
 func (r *relations) GetRelation(k string) Relation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	if gr, ok := r.storedRelation[k]; ok {
 		return gr
 	}
@@ -157,25 +378,33 @@ func isReservedKey(rk []string, k string) bool {
 // SetRelation ...
 func (r *relations) SetRelation(k string, v Relation) error {
 	if !isReservedKey(r.rk, k) {
-		r.storedRelation[k] = v
+		r.store(k, v)
 		return nil
 	}
 	return reservedKeyError(k)
 }
 
+// store sets relation k, dropping cached TimeFuncs which may depend on it.
+func (r *relations) store(k string, v Relation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.storedRelation[k] = v
+	r.storedTfn = make(map[string]TimeFunc)
+}
+
 // SetDirect ...
 func (r *relations) SetDirect(k string, v time.Time) error {
 	if !isReservedKey(r.rk, k) {
-		r.storedRelation[k] = wrapRelative(v)
+		r.store(k, wrapRelative(v))
 		return nil
 	}
 	return reservedKeyError(k)
 }
 
 func wrapRelative(t time.Time) Relation {
-	return newRelation(func(ti *Tart) TimeFunc {
+	return newRelation(func(c *Context) TimeFunc {
 		return func() time.Time {
-			return pumpShift(t, ti.last)
+			return c.Shift(t)
 		}
 	})
 }
@@ -185,7 +414,7 @@ func (r *relations) SetParsedDate(k, v string) error {
 	if isReservedKey(r.rk, k) {
 		return reservedKeyError(k)
 	}
-	now := time.Now()
+	now := r.t.clock.Now()
 	t, pErr := dateparse.ParseIn(v, r.t.Location())
 	if pErr != nil {
 		return pErr
@@ -193,7 +422,7 @@ func (r *relations) SetParsedDate(k, v string) error {
 	if y := t.Year(); y <= 0 {
 		t = t.AddDate(now.Year(), 0, 0)
 	}
-	r.storedRelation[k] = wrapRelative(t)
+	r.store(k, wrapRelative(t))
 	return nil
 }
 
@@ -223,27 +452,45 @@ func (r *relations) SetBatch(in ...map[string]Relation) error {
 }
 
 // Now returns TimeFunc for "now" from string "now" where "now" is tart.Time
-func Now(t *Tart) TimeFunc {
-	nt := pumpShift(t.Time, t.last)
+func Now(t *Context) TimeFunc {
+	nt := t.Shift(t.Time)
 	return func() time.Time {
 		return nt
 	}
 }
 
-func pumpShift(t time.Time, d *directive) time.Time {
-	if d != nil {
-		sh := d.Shift()
-		if len(sh) > 0 {
-			for _, v := range sh {
-				t = t.Add(v.dur).AddDate(v.y, v.m, v.d)
-			}
+// Shift returns the provided time shifted by the modifiers of the directive
+// evaluated, each in the order of Period.AddTo, business days skipping the
+// holidays of the Tart instance, months and years by the MonthEnd policy of
+// the directive or instance, and clock units by the ClockMode and LocalTime
+// policies of the instance.
+func (c *Context) Shift(t time.Time) time.Time {
+	d := c.Directive
+	if d == nil {
+		return t
+	}
+	p := c.policy()
+	for _, v := range d.shifters() {
+		var err error
+		if t, err = p.addClock(t, v.Duration, p.mode); err != nil {
+			c.fail(err)
+		}
+		if t, err = p.addClock(t, v.wall, ClockWall); err != nil {
+			c.fail(err)
+		}
+		t = t.Add(v.abs)
+		if t, err = p.addDate(t, v.Years, v.Months, v.Days); err != nil {
+			c.fail(err)
+		}
+		if v.bd != 0 {
+			t = c.Tart.addBusinessDays(c.Time, t, v.bd)
 		}
 	}
 	return t
 }
 
 // Yesterday returns TimeFunc giving local date for yesterday, with time 00:00:00.
-func Yesterday(t *Tart) TimeFunc {
+func Yesterday(t *Context) TimeFunc {
 	yt := t.Add(-(time.Hour * 24))
 	yd := time.Date(
 		yt.Year(),
@@ -252,14 +499,14 @@ func Yesterday(t *Tart) TimeFunc {
 		0, 0, 0, 0,
 		yt.Location(),
 	)
-	yd = pumpShift(yd, t.last)
+	yd = t.Shift(yd)
 	return func() time.Time {
 		return yd
 	}
 }
 
 // Today returns TimeFunc giving current local date, with time 00:00:00.
-func Today(t *Tart) TimeFunc {
+func Today(t *Context) TimeFunc {
 	tn := t.Time
 	td := time.Date(
 		tn.Year(),
@@ -268,14 +515,14 @@ func Today(t *Tart) TimeFunc {
 		0, 0, 0, 0,
 		tn.Location(),
 	)
-	td = pumpShift(td, t.last)
+	td = t.Shift(td)
 	return func() time.Time {
 		return td
 	}
 }
 
 // EOD returns TimeFunc for "eod" where end of day is current local date, with time 23:59:59.
-func EOD(t *Tart) TimeFunc {
+func EOD(t *Context) TimeFunc {
 	tn := t.Time
 	eod := time.Date(
 		tn.Year(),
@@ -284,14 +531,14 @@ func EOD(t *Tart) TimeFunc {
 		23, 59, 59, 0,
 		tn.Location(),
 	)
-	eod = pumpShift(eod, t.last)
+	eod = t.Shift(eod)
 	return func() time.Time {
 		return eod
 	}
 }
 
 // Tomorrow returns TimeFunc for "tomorrow" as local date for tomorrow, with time 00:00:00. Same as sod(start of day).
-func Tomorrow(t *Tart) TimeFunc {
+func Tomorrow(t *Context) TimeFunc {
 	tt := t.Add(time.Hour * 24)
 	tm := time.Date(
 		tt.Year(),
@@ -300,7 +547,7 @@ func Tomorrow(t *Tart) TimeFunc {
 		0, 0, 0, 0,
 		tt.Location(),
 	)
-	tm = pumpShift(tm, t.last)
+	tm = t.Shift(tm)
 	return func() time.Time {
 		return tm
 	}
@@ -310,15 +557,15 @@ func days() *rn {
 	return ring(daysOfWeek())
 }
 
-func weekday(t *Tart) string {
+func weekday(t *Context) string {
 	return strings.ToLower(t.Weekday().String())
 }
 
-// NominalDay returns a Relation. The subsequent TimeFunc returned generates
-// local date for the specified day(monday, tuesday, etc), after today, with
-// time 00:00:00.
+// NominalDay returns a Relation, recurring weekly. The subsequent TimeFunc
+// returned generates local date for the specified day(monday, tuesday, etc),
+// after today, with time 00:00:00.
 func NominalDay(t *Tart, d string) Relation {
-	return newRelation(func(t *Tart) TimeFunc {
+	return WithPeriod(Recurring(func(t *Context) TimeFunc {
 		sd := weekday(t)
 		dys := days()
 		jump := dys.jump(sd, d)
@@ -329,14 +576,14 @@ func NominalDay(t *Tart, d string) Relation {
 			0, 0, 0, 0,
 			t.Location(),
 		)
-		nd = pumpShift(nd, t.last)
+		nd = t.Shift(nd)
 		return func() time.Time {
 			return nd
 		}
-	})
+	}, Every(0, 0, 7)), DayOf)
 }
 
-func weekJump(t *Tart, v string, sub int, timeSub ...int) TimeFunc {
+func weekJump(t *Context, v string, sub int, timeSub ...int) TimeFunc {
 	sd := weekday(t)
 	dys := days()
 	jump := dys.jump(sd, v) - sub
@@ -360,7 +607,7 @@ func weekJump(t *Tart, v string, sub int, timeSub ...int) TimeFunc {
 		hour, min, secs, 0,
 		t.Location(),
 	)
-	w = pumpShift(w, t.last)
+	w = t.Shift(w)
 	return func() time.Time {
 		return w
 	}
@@ -368,31 +615,31 @@ func weekJump(t *Tart, v string, sub int, timeSub ...int) TimeFunc {
 
 // SOW returns TimeFunc providing local date for the next Sunday, with time
 // 00:00:00.
-func SOW(t *Tart) TimeFunc {
+func SOW(t *Context) TimeFunc {
 	return weekJump(t, "sunday", 0)
 }
 
 // SOCW returns TimeFunc providing local date for the last Sunday, with time
 // 00:00:00.
-func SOCW(t *Tart) TimeFunc {
+func SOCW(t *Context) TimeFunc {
 	return weekJump(t, "sunday", 7)
 }
 
 // EOW returns TimeFunc for local date for the end of the week, Saturday night,
 // with time 00:00:00.
-func EOW(t *Tart) TimeFunc {
+func EOW(t *Context) TimeFunc {
 	return weekJump(t, "saturday", 0)
 }
 
 // SOWW returns TimeFunc providing local date for the start of the work week,
 // next Monday, with time 00:00:00.
-func SOWW(t *Tart) TimeFunc {
+func SOWW(t *Context) TimeFunc {
 	return weekJump(t, "monday", 0)
 }
 
 // EOWW returns TimeFunc for local date for the end of the work week, Friday
 // night, with time 23:59:59.
-func EOWW(t *Tart) TimeFunc {
+func EOWW(t *Context) TimeFunc {
 	return weekJump(t, "friday", 0, 23, 59, 59)
 }
 
@@ -400,15 +647,15 @@ func months() *rn {
 	return ring(monthsOfYear())
 }
 
-func monthString(t *Tart) string {
+func monthString(t *Context) string {
 	return strings.ToLower(t.Month().String())
 }
 
-// NominalMonth returns a RelativeFunc returning a subsequent TimeFunc for
-// local date for the specified month(january, february, etc), 1st day, with
-// time 00:00:00.
+// NominalMonth returns a Relation, recurring yearly, returning a subsequent
+// TimeFunc for local date for the specified month(january, february, etc), 1st
+// day, with time 00:00:00.
 func NominalMonth(t *Tart, m string) Relation {
-	return newRelation(func(t *Tart) TimeFunc {
+	return WithPeriod(Recurring(func(t *Context) TimeFunc {
 		sm := monthString(t)
 		mths := months()
 		mn := mths.jump("january", sm) + 1
@@ -420,16 +667,65 @@ func NominalMonth(t *Tart, m string) Relation {
 			0, 0, 0, 0,
 			t.Location(),
 		)
-		nm = pumpShift(nm, t.last)
+		nm = t.Shift(nm)
 		return func() time.Time {
 			return nm
 		}
-	})
+	}, Every(1, 0, 0)), MonthOf)
+}
+
+// quarterPrefix is the prefix of a point given as a quarter of the year, e.g.
+// "!quarter:3".
+const quarterPrefix = "quarter:"
+
+// relationPrefixes are the prefixes of points evaluated as the relation of
+// the parameter following rather than looked up as relations.
+var relationPrefixes = map[string]func(string) (Relation, error){
+	quarterPrefix: func(v string) (Relation, error) {
+		q, err := strconv.Atoi(v)
+		if err != nil || q < 1 || q > 4 {
+			return nil, fmt.Errorf("quarter '%s': expected 1 to 4", v)
+		}
+		return Quarter(q), nil
+	},
+}
+
+// quarterRelation returns the Quarter of a point "q1" through "q4", short for
+// "quarter:1" through "quarter:4", nil for other points. Relations set with
+// these keys come first.
+func quarterRelation(phrase string) Relation {
+	if len(phrase) == 2 && phrase[0] == 'q' && phrase[1] >= '1' && phrase[1] <= '4' {
+		return Quarter(int(phrase[1] - '0'))
+	}
+	return nil
+}
+
+// Quarter returns a Relation, recurring yearly, returning a subsequent
+// TimeFunc for local date for the 1st day of the specified quarter(1 to 4) of
+// this year until that quarter is over, then of next year, with time
+// 00:00:00.
+func Quarter(q int) Relation {
+	return WithPeriod(Recurring(func(t *Context) TimeFunc {
+		sq := time.Date(
+			t.Year(),
+			time.Month(3*(q-1)+1),
+			1,
+			0, 0, 0, 0,
+			t.Location(),
+		)
+		if !t.Before(sq.AddDate(0, 3, 0)) {
+			sq = sq.AddDate(1, 0, 0)
+		}
+		sq = t.Shift(sq)
+		return func() time.Time {
+			return sq
+		}
+	}, Every(1, 0, 0)), QuarterOf)
 }
 
 // SOCM returns TimeFunc for local date for the 1st day of the current month,
 // with time 00:00:00.
-func SOCM(t *Tart) TimeFunc {
+func SOCM(t *Context) TimeFunc {
 	sm := time.Date(
 		t.Year(),
 		t.Month(),
@@ -437,7 +733,7 @@ func SOCM(t *Tart) TimeFunc {
 		0, 0, 0, 0,
 		t.Location(),
 	)
-	sm = pumpShift(sm, t.last)
+	sm = t.Shift(sm)
 	return func() time.Time {
 		return sm
 	}
@@ -445,7 +741,7 @@ func SOCM(t *Tart) TimeFunc {
 
 // SOM returns TimeFunc providing local date for the 1st day of the next month,
 // with time 00:00:00.
-func SOM(t *Tart) TimeFunc {
+func SOM(t *Context) TimeFunc {
 	sm := monthString(t)
 	mths := months()
 	mn := mths.jump("january", sm) + 1
@@ -456,7 +752,7 @@ func SOM(t *Tart) TimeFunc {
 		0, 0, 0, 0,
 		t.Location(),
 	)
-	m = pumpShift(m, t.last)
+	m = t.Shift(m)
 	return func() time.Time {
 		return m
 	}
@@ -464,7 +760,7 @@ func SOM(t *Tart) TimeFunc {
 
 // EOM returns TimeFunc providing local date for the last day of the current
 // month, with time 23:59:59.
-func EOM(t *Tart) TimeFunc {
+func EOM(t *Context) TimeFunc {
 	sm := monthString(t)
 	mths := months()
 	mn := mths.jump("january", sm) + 1
@@ -476,7 +772,7 @@ func EOM(t *Tart) TimeFunc {
 		t.Location(),
 	)
 	d = d.Add(-(24 * time.Hour))
-	d = pumpShift(d, t.last)
+	d = t.Shift(d)
 	return func() time.Time {
 		return d
 	}
@@ -497,7 +793,7 @@ func quarters(year int, z *time.Location) map[string]time.Time {
 
 // SOQ returns TimeFunc providing local date for the start of the next quarter
 // (January, April, July, October), 1st, with time 00:00:00.
-func SOQ(t *Tart) TimeFunc {
+func SOQ(t *Context) TimeFunc {
 	q := quarters(t.Year(), t.Location())
 	var qt time.Time
 	switch {
@@ -511,7 +807,7 @@ func SOQ(t *Tart) TimeFunc {
 		qt = q["Q1"]
 	}
 
-	qt = pumpShift(qt, t.last)
+	qt = t.Shift(qt)
 
 	return func() time.Time {
 		return qt
@@ -521,7 +817,7 @@ func SOQ(t *Tart) TimeFunc {
 // EOQ returns TimeFunc providing local date for the end of the current quarter
 // (March, June, September, December), last day of the month, with time
 // 23:59:59.
-func EOQ(t *Tart) TimeFunc {
+func EOQ(t *Context) TimeFunc {
 	q := quarters(t.Year(), t.Location())
 	var qt time.Time
 	switch {
@@ -535,7 +831,7 @@ func EOQ(t *Tart) TimeFunc {
 		qt = q["Q4x"]
 	}
 
-	qt = pumpShift(qt, t.last)
+	qt = t.Shift(qt)
 
 	return func() time.Time {
 		return qt
@@ -544,7 +840,7 @@ func EOQ(t *Tart) TimeFunc {
 
 // SOY returns TimeFunc providing local date for the next year, January 1st,
 // with time 00:00:00.
-func SOY(t *Tart) TimeFunc {
+func SOY(t *Context) TimeFunc {
 	sy := time.Date(
 		t.Year()+1,
 		time.January,
@@ -552,7 +848,7 @@ func SOY(t *Tart) TimeFunc {
 		0, 0, 0, 0,
 		t.Location(),
 	)
-	sy = pumpShift(sy, t.last)
+	sy = t.Shift(sy)
 
 	return func() time.Time {
 		return sy
@@ -561,7 +857,7 @@ func SOY(t *Tart) TimeFunc {
 
 // EOY returns TimeFunc providing local date for this year, December 31st, with
 // time 00:00:00.
-func EOY(t *Tart) TimeFunc {
+func EOY(t *Context) TimeFunc {
 	ey := time.Date(
 		t.Year(),
 		time.December,
@@ -569,7 +865,7 @@ func EOY(t *Tart) TimeFunc {
 		0, 0, 0, 0,
 		t.Location(),
 	)
-	ey = pumpShift(ey, t.last)
+	ey = t.Shift(ey)
 
 	return func() time.Time {
 		return ey
@@ -578,7 +874,7 @@ func EOY(t *Tart) TimeFunc {
 
 // Whenever returns TimeFunc for "whenever", "later", "someday" mapped to local
 // 2077-04-27, with time 14:37:00. A date far away.
-func Whenever(t *Tart) TimeFunc {
+func Whenever(t *Context) TimeFunc {
 	we := time.Date(
 		2077,
 		time.Month(4),
@@ -586,22 +882,22 @@ func Whenever(t *Tart) TimeFunc {
 		14, 37, 0, 0,
 		t.Location(),
 	)
-	we = pumpShift(we, t.last)
+	we = t.Shift(we)
 
 	return func() time.Time {
 		return we
 	}
 }
 
-// Any returns TimeFunc that attempts to parse Tart.last to a valid time.
-func Any(t *Tart) TimeFunc {
-	now := time.Now()
-	d := t.last
+// Any returns TimeFunc that attempts to parse the point of the directive
+// evaluated to a valid time.
+func Any(t *Context) TimeFunc {
+	d := t.Directive
 	ret, _ := dateparse.ParseIn(d.phrase, t.Location())
 	if y := ret.Year(); y <= 0 {
-		ret = ret.AddDate(now.Year(), 0, 0)
+		ret = ret.AddDate(t.Time.Year(), 0, 0)
 	}
-	ret = pumpShift(ret, t.last)
+	ret = t.Shift(ret)
 
 	return func() time.Time {
 		return ret
@@ -0,0 +1,613 @@
+package tart
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type frequency int
+
+const (
+	minutely frequency = iota
+	hourly
+	daily
+	weekly
+	monthly
+	yearly
+)
+
+var frequencies = map[string]frequency{
+	"MINUTELY": minutely,
+	"HOURLY":   hourly,
+	"DAILY":    daily,
+	"WEEKLY":   weekly,
+	"MONTHLY":  monthly,
+	"YEARLY":   yearly,
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// byDay is a BYDAY value, the nth weekday of the period, every such weekday
+// for n of 0.
+type byDay struct {
+	n  int
+	wd time.Weekday
+}
+
+// rrule is an RFC 5545 recurrence rule from a start time, a schedule. An
+// open rule occurs before its start as well, the start giving only the time
+// of day and the phase of its occurrences.
+type rrule struct {
+	start      time.Time
+	open       bool
+	freq       frequency
+	interval   int
+	count      int
+	until      time.Time
+	wkst       time.Weekday
+	byMonth    []int
+	byMonthDay []int
+	byDay      []byDay
+	bySetPos   []int
+}
+
+func rruleError(in, msg string, a ...interface{}) error {
+	return fmt.Errorf("recurrence rule '%s': %s", in, fmt.Sprintf(msg, a...))
+}
+
+func parseInts(v string, min, max int) ([]int, error) {
+	var ret []int
+	for _, s := range strings.Split(v, ",") {
+		i, err := strconv.Atoi(s)
+		if err != nil || i == 0 || i < min || i > max {
+			return nil, fmt.Errorf("out of range")
+		}
+		ret = append(ret, i)
+	}
+	return ret, nil
+}
+
+func parseByDay(v string) ([]byDay, error) {
+	var ret []byDay
+	for _, s := range strings.Split(strings.ToUpper(v), ",") {
+		if len(s) < 2 {
+			return nil, fmt.Errorf("malformed")
+		}
+		wd, ok := weekdayCodes[s[len(s)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("malformed")
+		}
+		var n int
+		if p := s[:len(s)-2]; p != "" {
+			var err error
+			if n, err = strconv.Atoi(p); err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("malformed")
+			}
+		}
+		ret = append(ret, byDay{n, wd})
+	}
+	return ret, nil
+}
+
+// parseRRule parses the provided RFC 5545 RRULE value, e.g.
+// "FREQ=MONTHLY;BYDAY=2TU;COUNT=10", recurring from the provided start, as an
+// open rule where open and without COUNT.
+func parseRRule(in string, start time.Time, open bool) (*rrule, error) {
+	r := &rrule{start: start, interval: 1, wkst: time.Monday}
+	var hasFreq bool
+	for _, part := range strings.Split(strings.TrimPrefix(in, "RRULE:"), ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, rruleError(in, "malformed part '%s'", part)
+		}
+		k, v := strings.ToUpper(kv[0]), kv[1]
+		var err error
+		switch k {
+		case "FREQ":
+			r.freq, hasFreq = frequencies[strings.ToUpper(v)]
+			if !hasFreq {
+				return nil, rruleError(in, "unsupported frequency '%s'", v)
+			}
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(v)
+			if err == nil && r.interval < 1 {
+				err = fmt.Errorf("below 1")
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(v)
+			if err == nil && r.count < 1 {
+				err = fmt.Errorf("below 1")
+			}
+		case "UNTIL":
+			r.until, err = parseICSTime(v, nil, start.Location())
+		case "WKST":
+			var ok bool
+			if r.wkst, ok = weekdayCodes[strings.ToUpper(v)]; !ok {
+				err = fmt.Errorf("malformed")
+			}
+		case "BYMONTH":
+			r.byMonth, err = parseInts(v, 1, 12)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseInts(v, -31, 31)
+		case "BYDAY":
+			r.byDay, err = parseByDay(v)
+		case "BYSETPOS":
+			r.bySetPos, err = parseInts(v, -366, 366)
+		default:
+			return nil, rruleError(in, "unsupported part '%s'", k)
+		}
+		if err != nil {
+			return nil, rruleError(in, "invalid %s '%s'", k, v)
+		}
+	}
+	if !hasFreq {
+		return nil, rruleError(in, "missing FREQ")
+	}
+	r.open = open && r.count == 0
+	if !r.until.IsZero() && r.until.Before(start) && !r.open {
+		return nil, rruleError(in, "UNTIL before the start %s", start.Format(time.RFC3339))
+	}
+	if r.never() {
+		return nil, rruleError(in, "never occurs")
+	}
+	return r, nil
+}
+
+// never reports whether the BY values of the rule exclude every occurrence,
+// e.g. BYMONTHDAY=30 with BYMONTH=2.
+func (r *rrule) never() bool {
+	months := r.byMonth
+	if len(months) == 0 {
+		months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	}
+	if len(r.byMonthDay) > 0 && r.freq != weekly {
+		var held bool
+		for _, m := range months {
+			n := time.Date(2020, time.Month(m)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+			for _, d := range r.byMonthDay {
+				held = held || (d <= n && -d <= n)
+			}
+		}
+		if !held {
+			return true
+		}
+	}
+	if len(r.byDay) > 0 && len(r.byMonthDay) == 0 && (r.freq == monthly || (r.freq == yearly && len(r.byMonth) > 0)) {
+		var held bool
+		for _, bd := range r.byDay {
+			held = held || (bd.n <= 5 && -bd.n <= 5)
+		}
+		if !held {
+			return true
+		}
+	}
+	if len(r.bySetPos) > 0 && r.freq <= weekly {
+		size := 1
+		if r.freq == weekly && len(r.byDay) > 0 {
+			size = len(r.limitWeekday(daysOf(r.start, r.start.AddDate(0, 0, 7))))
+		}
+		var held bool
+		for _, p := range r.bySetPos {
+			held = held || (p <= size && -p <= size)
+		}
+		return !held
+	}
+	return false
+}
+
+// period returns the start of the nth period of the rule.
+func (r *rrule) period(n int) time.Time {
+	s := r.start
+	n = n * r.interval
+	switch r.freq {
+	case minutely:
+		return s.Add(time.Duration(n) * time.Minute)
+	case hourly:
+		return s.Add(time.Duration(n) * time.Hour)
+	case daily:
+		return s.AddDate(0, 0, n)
+	case weekly:
+		return s.AddDate(0, 0, 7*n-(int(s.Weekday()-r.wkst)+7)%7)
+	case monthly:
+		return time.Date(s.Year(), s.Month()+time.Month(n), 1, s.Hour(), s.Minute(), s.Second(), s.Nanosecond(), s.Location())
+	}
+	return time.Date(s.Year()+n, time.January, 1, s.Hour(), s.Minute(), s.Second(), s.Nanosecond(), s.Location())
+}
+
+// nthWeekdays returns the days from the provided days, in order, falling on
+// the weekdays of the BYDAY values, counting the nth of each within them.
+func (r *rrule) nthWeekdays(days []time.Time) []time.Time {
+	var ret []time.Time
+	for _, bd := range r.byDay {
+		var of []time.Time
+		for _, d := range days {
+			if d.Weekday() == bd.wd {
+				of = append(of, d)
+			}
+		}
+		switch {
+		case bd.n == 0:
+			ret = append(ret, of...)
+		case bd.n > 0 && bd.n <= len(of):
+			ret = append(ret, of[bd.n-1])
+		case bd.n < 0 && -bd.n <= len(of):
+			ret = append(ret, of[len(of)+bd.n])
+		}
+	}
+	return ret
+}
+
+// monthDays returns the days of the month of the provided time matching the
+// BYMONTHDAY and BYDAY values, the day of the start where there are neither.
+func (r *rrule) monthDays(p time.Time) []time.Time {
+	days := daysOf(p, time.Date(p.Year(), p.Month()+1, 1, 0, 0, 0, 0, time.UTC))
+	if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+		if d := r.start.Day(); d <= len(days) {
+			return days[d-1 : d]
+		}
+		return nil
+	}
+	if len(r.byMonthDay) > 0 {
+		var md []time.Time
+		for _, d := range r.byMonthDay {
+			if d < 0 {
+				d = len(days) + 1 + d
+			}
+			if d >= 1 && d <= len(days) {
+				md = append(md, days[d-1])
+			}
+		}
+		days = md
+	}
+	if len(r.byDay) > 0 {
+		if len(r.byMonthDay) > 0 {
+			return r.limitWeekday(days)
+		}
+		return r.nthWeekdays(days)
+	}
+	return days
+}
+
+// daysOf returns the days from the day of the provided time to the day
+// before the provided end, at midnight UTC.
+func daysOf(from, to time.Time) []time.Time {
+	var ret []time.Time
+	for d := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC); d.Before(to); d = d.AddDate(0, 0, 1) {
+		ret = append(ret, d)
+	}
+	return ret
+}
+
+func (r *rrule) limitWeekday(days []time.Time) []time.Time {
+	var ret []time.Time
+	for _, d := range days {
+		for _, bd := range r.byDay {
+			if d.Weekday() == bd.wd {
+				ret = append(ret, d)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// limited reports whether the provided day is excluded by the BY values
+// limiting rather than expanding the rule.
+func (r *rrule) limited(d time.Time) bool {
+	if len(r.byMonth) > 0 && !containsInt(r.byMonth, int(d.Month())) {
+		return true
+	}
+	if r.freq < weekly {
+		if len(r.byDay) > 0 && len(r.limitWeekday([]time.Time{d})) == 0 {
+			return true
+		}
+		if len(r.byMonthDay) > 0 {
+			n := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+			if !containsInt(r.byMonthDay, d.Day()) && !containsInt(r.byMonthDay, d.Day()-n-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsInt(is []int, i int) bool {
+	for _, v := range is {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+// days returns the days of the rule in the period starting at the provided
+// time, at midnight UTC.
+func (r *rrule) days(p time.Time) []time.Time {
+	switch r.freq {
+	case weekly:
+		days := daysOf(p, time.Date(p.Year(), p.Month(), p.Day()+7, 0, 0, 0, 0, time.UTC))
+		if len(r.byDay) == 0 {
+			return days[(int(r.start.Weekday()-r.wkst)+7)%7:][:1]
+		}
+		return r.limitWeekday(days)
+	case monthly:
+		return r.monthDays(p)
+	case yearly:
+		switch {
+		case len(r.byMonth) > 0:
+			var ret []time.Time
+			for _, m := range r.byMonth {
+				ret = append(ret, r.monthDays(time.Date(p.Year(), time.Month(m), 1, 0, 0, 0, 0, time.UTC))...)
+			}
+			return ret
+		case len(r.byMonthDay) > 0:
+			var ret []time.Time
+			for m := time.January; m <= time.December; m++ {
+				ret = append(ret, r.monthDays(time.Date(p.Year(), m, 1, 0, 0, 0, 0, time.UTC))...)
+			}
+			return ret
+		case len(r.byDay) > 0:
+			return r.nthWeekdays(daysOf(p, time.Date(p.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)))
+		}
+		return r.monthDays(time.Date(p.Year(), r.start.Month(), 1, 0, 0, 0, 0, time.UTC))
+	}
+	return daysOf(p, time.Date(p.Year(), p.Month(), p.Day()+1, 0, 0, 0, 0, time.UTC))
+}
+
+// expand returns the occurrences of the rule in the period starting at the
+// provided time, in order.
+func (r *rrule) expand(p time.Time) []time.Time {
+	var ret []time.Time
+	for _, d := range r.days(p) {
+		if r.limited(d) {
+			continue
+		}
+		o := p
+		if r.freq > hourly {
+			s := r.start
+			o = time.Date(d.Year(), d.Month(), d.Day(), s.Hour(), s.Minute(), s.Second(), s.Nanosecond(), s.Location())
+		}
+		ret = append(ret, o)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Before(ret[j]) })
+	for i := 1; i < len(ret); i++ {
+		if ret[i].Equal(ret[i-1]) {
+			ret = append(ret[:i], ret[i+1:]...)
+			i--
+		}
+	}
+	if len(r.bySetPos) == 0 {
+		return ret
+	}
+	var sel []time.Time
+	for i, o := range ret {
+		if containsInt(r.bySetPos, i+1) || containsInt(r.bySetPos, i-len(ret)) {
+			sel = append(sel, o)
+		}
+	}
+	return sel
+}
+
+// floorDiv returns a divided by b, rounded toward negative infinity.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// civilDay returns the days from the Unix epoch to the date of the provided
+// time.
+func civilDay(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// index returns the index of the period of the rule containing the provided
+// time, negative before the first.
+func (r *rrule) index(t time.Time) int {
+	s := r.start
+	t = t.In(s.Location())
+	var n int
+	switch r.freq {
+	case minutely:
+		n = floorDiv(int(t.Sub(s)/time.Second), 60)
+	case hourly:
+		n = floorDiv(int(t.Sub(s)/time.Second), 3600)
+	case daily:
+		n = civilDay(t) - civilDay(s)
+	case weekly:
+		n = floorDiv(civilDay(t)-civilDay(r.period(0)), 7)
+	case monthly:
+		n = (t.Year()-s.Year())*12 + int(t.Month()) - int(s.Month())
+	case yearly:
+		n = t.Year() - s.Year()
+	}
+	return floorDiv(n, r.interval)
+}
+
+// excluded reports whether the day of a period of the rule, of less than a
+// day, is excluded by the BY values.
+func (r *rrule) excluded(p time.Time) bool {
+	return r.freq < daily && r.limited(time.Date(p.Year(), p.Month(), p.Day(), 0, 0, 0, 0, time.UTC))
+}
+
+// occurrences returns the occurrences of the nth period, in order, from the
+// start, unless the rule is open, and not after UNTIL.
+func (r *rrule) occurrences(n int) []time.Time {
+	var ret []time.Time
+	for _, o := range r.expand(r.period(n)) {
+		if (r.open || !o.Before(r.start)) && (r.until.IsZero() || !o.After(r.until)) {
+			ret = append(ret, o)
+		}
+	}
+	return ret
+}
+
+// counted calls the provided function with each occurrence of a rule with a
+// COUNT in order, until it returns false, the occurrences are exhausted or a
+// period starts after the provided time.
+func (r *rrule) counted(to time.Time, fn func(time.Time) bool) {
+	var n int
+	for i := 0; !r.period(i).After(to); i++ {
+		if r.excluded(r.period(i)) {
+			i = r.skip(i, 1)
+			continue
+		}
+		for _, o := range r.occurrences(i) {
+			if n >= r.count || !fn(o) {
+				return
+			}
+			n++
+		}
+		if !r.until.IsZero() && r.period(i).After(r.until) {
+			return
+		}
+	}
+}
+
+// skip returns the period before the first period of the following day, for
+// dir 1, or the period after the last period of the preceding day, for dir -1,
+// from the nth period, to resume the search after the day of the nth period.
+func (r *rrule) skip(n, dir int) int {
+	p := r.period(n)
+	sod := time.Date(p.Year(), p.Month(), p.Day(), 0, 0, 0, 0, p.Location())
+	if dir > 0 {
+		if i := r.index(sod.AddDate(0, 0, 1)) - 1; i > n {
+			return i
+		}
+		return n
+	}
+	if i := r.index(sod) + 1; i < n {
+		return i
+	}
+	return n
+}
+
+// next returns the first occurrence not before the provided time, searching
+// maxCalendarYears ahead.
+func (r *rrule) next(t time.Time) (time.Time, bool) {
+	to := t.AddDate(maxCalendarYears, 0, 0)
+	if r.count > 0 {
+		var ret time.Time
+		var found bool
+		r.counted(to, func(o time.Time) bool {
+			if !o.Before(t) {
+				ret, found = o, true
+			}
+			return !found
+		})
+		return ret, found
+	}
+	if !r.until.IsZero() && r.until.Before(to) {
+		to = r.until
+	}
+	i := r.index(t) - 1
+	if i < 0 && !r.open {
+		i = 0
+	}
+	for ; !r.period(i).After(to); i++ {
+		if r.excluded(r.period(i)) {
+			i = r.skip(i, 1)
+			continue
+		}
+		for _, o := range r.occurrences(i) {
+			if !o.Before(t) {
+				return o, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// prev returns the last occurrence before the provided time, searching
+// maxCalendarYears back.
+func (r *rrule) prev(t time.Time) (time.Time, bool) {
+	if r.count > 0 {
+		var ret time.Time
+		var found bool
+		r.counted(t, func(o time.Time) bool {
+			if o.Before(t) {
+				ret, found = o, true
+				return true
+			}
+			return false
+		})
+		return ret, found
+	}
+	if !r.until.IsZero() && t.After(r.until) {
+		t = r.until.Add(time.Nanosecond)
+	}
+	from := t.AddDate(-maxCalendarYears, 0, 0)
+	for i := r.index(t) + 1; i >= 0 || r.open; i-- {
+		p := r.period(i)
+		if p.Before(from) {
+			break
+		}
+		if r.excluded(p) {
+			i = r.skip(i, -1)
+			continue
+		}
+		os := r.occurrences(i)
+		for j := len(os) - 1; j >= 0; j-- {
+			if os[j].Before(t) {
+				return os[j], true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// SetRecurrence sets relation k recurring as the provided RFC 5545 recurrence
+// rule, e.g.
+//
+//	t.SetRecurrence("payday", "FREQ=MONTHLY;BYMONTHDAY=15,-1")
+//	t.SetRecurrence("standup", "DTSTART:20190701T093000\nRRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR")
+//	t.SetRecurrence("sprint-review", "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR")
+//
+// FREQ of MINUTELY to YEARLY, INTERVAL, COUNT, UNTIL, WKST, BYMONTH,
+// BYMONTHDAY, BYDAY and BYSETPOS are supported, and a rule whose BY values
+// exclude every occurrence, e.g. BYMONTHDAY=30 with BYMONTH=2, is an error.
+// The rule recurs from the DTSTART given ahead of it, in the location of the
+// instance where without TZID or UTC designation. Without DTSTART the start
+// of the day of the instance time gives the time of day of its occurrences
+// and the phase of INTERVAL, and the rule occurs before that day as after
+// it, but for a rule with COUNT, which counts from that day. "!payday" is the
+// next occurrence, and '+' and '-' step from occurrence to occurrence.
+// Occurrences are searched for up to 100 years from the anchor.
+func (t *Tart) SetRecurrence(k, rule string) error {
+	t.mu.RLock()
+	start := startOfDay(t.Time)
+	t.mu.RUnlock()
+	open := true
+	if ls, err := readICSLines(strings.NewReader(rule)); err == nil && len(ls) > 1 {
+		for _, l := range ls {
+			switch l.name {
+			case "DTSTART":
+				if start, err = parseICSTime(l.value, l.params, start.Location()); err != nil {
+					return rruleError(rule, "invalid DTSTART '%s': %s", l.value, err)
+				}
+				open = false
+			case "RRULE":
+				rule = l.value
+			}
+		}
+	}
+	r, err := parseRRule(rule, start, open)
+	if err != nil {
+		return err
+	}
+	return t.SetRelation(k, scheduleRelation(r))
+}
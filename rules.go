@@ -0,0 +1,252 @@
+package tart
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRules returns a Config setting relations from the JSON or YAML rule
+// file read from the provided reader, an array of rules of the form:
+//
+//	[
+//	  {"name": "founders", "month": "march", "day": 14, "holiday": true,
+//	   "observed": "weekend", "first_year": 2005},
+//	  {"name": "summer-friday", "month": "august", "weekday": "friday", "nth": -1},
+//	  {"name": "black-friday", "offset": {"relation": "thanksgiving", "days": 1}}
+//	]
+//
+// or, as YAML:
+//
+//	# holidays.yaml
+//	- name: founders
+//	  month: march
+//	  day: 14
+//	  holiday: true
+//	  observed: weekend
+//	  first_year: 2005
+//	- name: black-friday
+//	  offset: {relation: thanksgiving, days: 1}
+//
+// A file leading with '[' is read as JSON, any other as YAML.
+//
+// Each rule has a name and one of:
+//
+//	"month" & "day"                 = a fixed date
+//	"month", "weekday" & "nth"      = the nth weekday of the month, counting
+//	                                  from the end for negative nth, -1 the last,
+//	                                  skipping years without one
+//	"offset" {"relation", "days"}   = whole days from a relation, either a
+//	                                  rule earlier in the file or a relation
+//	                                  of the Tart instance
+//
+// Months and weekdays are names or numbers (january = 1, sunday = 0).
+// Optionally:
+//
+//	"holiday"                  = true marks the relation as a holiday
+//	"first_year", "last_year"  = the years the rule is held, inclusive
+//	"observed"                 = "weekend", moving saturday to friday and
+//	                             sunday to monday, "monday", moving both to
+//	                             monday, or "sunday", moving only sunday to
+//	                             monday
+//
+// A rule with an observed policy sets the observed day as "<name>-observed",
+// the holiday where marked, alongside the actual date as "<name>".
+func LoadRules(r io.Reader) Config {
+	return func(t *Tart) error {
+		rs, err := readRules(r)
+		if err != nil {
+			return fmt.Errorf("reading rules: %s", err)
+		}
+		hs, err := loadRules(t, rs)
+		if err != nil {
+			return err
+		}
+		return t.SetBatch(hs)
+	}
+}
+
+func readRules(r io.Reader) ([]rule, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var rs []rule
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		err = json.Unmarshal(b, &rs)
+	} else {
+		err = yaml.Unmarshal(b, &rs)
+	}
+	return rs, err
+}
+
+type rule struct {
+	Name      string      `json:"name" yaml:"name"`
+	Month     nameOrIndex `json:"month" yaml:"month"`
+	Day       int         `json:"day" yaml:"day"`
+	Weekday   nameOrIndex `json:"weekday" yaml:"weekday"`
+	Nth       int         `json:"nth" yaml:"nth"`
+	Offset    *ruleOffset `json:"offset" yaml:"offset"`
+	Holiday   bool        `json:"holiday" yaml:"holiday"`
+	Observed  string      `json:"observed" yaml:"observed"`
+	FirstYear int         `json:"first_year" yaml:"first_year"`
+	LastYear  int         `json:"last_year" yaml:"last_year"`
+}
+
+type ruleOffset struct {
+	Relation string `json:"relation" yaml:"relation"`
+	Days     int    `json:"days" yaml:"days"`
+}
+
+// nameOrIndex is a month or weekday given as a name or a number.
+type nameOrIndex string
+
+func (n *nameOrIndex) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*n = nameOrIndex(strings.ToLower(s))
+		return nil
+	}
+	var i int
+	if err := json.Unmarshal(b, &i); err != nil {
+		return err
+	}
+	*n = nameOrIndex(strconv.Itoa(i))
+	return nil
+}
+
+func (n *nameOrIndex) UnmarshalYAML(v *yaml.Node) error {
+	if v.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: expected a name or a number", v.Line)
+	}
+	*n = nameOrIndex(strings.ToLower(v.Value))
+	return nil
+}
+
+func (n nameOrIndex) index(names []string, base int) (int, bool) {
+	for i, v := range names {
+		if string(n) == v {
+			return i + base, true
+		}
+	}
+	i, err := strconv.Atoi(string(n))
+	return i, err == nil && i >= base && i < len(names)+base
+}
+
+func ruleError(name, msg string, a ...interface{}) error {
+	return fmt.Errorf("rule '%s': %s", name, fmt.Sprintf(msg, a...))
+}
+
+func loadRules(t *Tart, rs []rule) (map[string]Relation, error) {
+	ret := make(map[string]Relation)
+	loaded := make(map[string]dateRule)
+	for _, v := range rs {
+		if v.Name == "" {
+			return nil, ruleError(v.Name, "missing name")
+		}
+		dr, err := v.dateRule(t, loaded)
+		if err != nil {
+			return nil, err
+		}
+		if v.FirstYear != 0 || v.LastYear != 0 {
+			dr = during(v.FirstYear, v.LastYear, dr)
+		}
+		loaded[v.Name] = dr
+		mark := func(rl Relation, holiday bool) Relation {
+			if holiday {
+				return AsHoliday(rl)
+			}
+			return rl
+		}
+		if v.Observed == "" || v.Observed == "none" {
+			ret[v.Name] = mark(dr.relation(), v.Holiday)
+			continue
+		}
+		obs, ok := observedPolicies[v.Observed]
+		if !ok {
+			return nil, ruleError(v.Name, "unknown observed policy '%s'", v.Observed)
+		}
+		ret[v.Name] = dr.relation()
+		ret[v.Name+"-observed"] = mark(obs(dr).relation(), v.Holiday)
+	}
+	return ret, nil
+}
+
+func (v rule) dateRule(t *Tart, loaded map[string]dateRule) (dateRule, error) {
+	switch {
+	case v.Offset != nil:
+		if dr, ok := loaded[v.Offset.Relation]; ok {
+			return fromRule(dr, v.Offset.Days), nil
+		}
+		if _, ok := t.GetRelation(v.Offset.Relation).(Recurrent); !ok {
+			return nil, ruleError(v.Name, "offset from '%s', not a recurrent relation", v.Offset.Relation)
+		}
+		return fromRule(relationRule(t, v.Offset.Relation), v.Offset.Days), nil
+	case v.Month == "":
+		return nil, ruleError(v.Name, "missing month")
+	}
+	m, ok := v.Month.index(monthsOfYear(), 1)
+	if !ok {
+		return nil, ruleError(v.Name, "unknown month '%s'", v.Month)
+	}
+	switch {
+	case v.Weekday != "":
+		wd, ok := v.Weekday.index(daysOfWeek(), 0)
+		if !ok {
+			return nil, ruleError(v.Name, "unknown weekday '%s'", v.Weekday)
+		}
+		if v.Nth == 0 || v.Nth < -5 || v.Nth > 5 {
+			return nil, ruleError(v.Name, "nth weekday must be 1 to 5 or -1 to -5")
+		}
+		return nthWeekday(time.Month(m), time.Weekday(wd), v.Nth), nil
+	case v.Day >= 1 && v.Day <= 31:
+		return fixedDate(time.Month(m), v.Day), nil
+	}
+	return nil, ruleError(v.Name, "missing day or weekday")
+}
+
+var observedPolicies = map[string]func(dateRule) dateRule{
+	"weekend": observed,
+	"monday": func(r dateRule) dateRule {
+		return func(y int, loc *time.Location) (time.Time, bool) {
+			o, ok := r(y, loc)
+			switch o.Weekday() {
+			case time.Saturday:
+				o = o.AddDate(0, 0, 2)
+			case time.Sunday:
+				o = o.AddDate(0, 0, 1)
+			}
+			return o, ok
+		}
+	},
+	"sunday": func(r dateRule) dateRule {
+		return func(y int, loc *time.Location) (time.Time, bool) {
+			o, ok := r(y, loc)
+			if o.Weekday() == time.Sunday {
+				o = o.AddDate(0, 0, 1)
+			}
+			return o, ok
+		}
+	},
+}
+
+// relationRule is the rule of relation k of the provided Tart instance, its
+// first occurrence in a year.
+func relationRule(t *Tart, k string) dateRule {
+	return func(y int, loc *time.Location) (time.Time, bool) {
+		rl := t.GetRelation(k)
+		if rl == nil {
+			return time.Time{}, false
+		}
+		start := time.Date(y, time.January, 1, 0, 0, 0, 0, loc)
+		c := &Context{Time: start, Tart: t, Directive: &Directive{origin: k, phrase: k}}
+		o := rl.Relative(c)()
+		return o, c.err == nil && o.Year() == y
+	}
+}
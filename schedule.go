@@ -0,0 +1,166 @@
+package tart
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// schedule is a set of occurrences in time.
+type schedule interface {
+	// next returns the first occurrence not before the provided time.
+	next(time.Time) (time.Time, bool)
+	// prev returns the last occurrence before the provided time.
+	prev(time.Time) (time.Time, bool)
+}
+
+// scheduleRelation returns a Recurrent relation for the provided schedule,
+// the next occurrence not before the anchor, stepping occurrence to
+// occurrence. A schedule without further occurrences fails the Context.
+func scheduleRelation(s schedule) Recurrent {
+	return Recurring(func(c *Context) TimeFunc {
+		o, ok := s.next(c.Time)
+		if ok {
+			o = c.Shift(o)
+		} else {
+			c.fail(noOccurrenceError(c))
+		}
+		return func() time.Time {
+			return o
+		}
+	}, func(t time.Time, n int) time.Time {
+		for ; n > 0; n-- {
+			o, ok := s.next(t.Add(time.Nanosecond))
+			if !ok {
+				break
+			}
+			t = o
+		}
+		for ; n < 0; n++ {
+			o, ok := s.prev(t)
+			if !ok {
+				break
+			}
+			t = o
+		}
+		return t
+	})
+}
+
+// schedulePrefixes are the prefixes of points evaluated as the schedule
+// expression following rather than looked up as relations.
+var schedulePrefixes = map[string]func(string) (schedule, error){
+	cronPrefix: func(expr string) (schedule, error) {
+		c, err := parseCron(expr)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	},
+	calendarPrefix: func(expr string) (schedule, error) {
+		e, err := parseCalendar(expr)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	},
+}
+
+// prefixRelation returns the relation of a point led by a schedule or relation
+// prefix, reporting whether the point has such a prefix.
+func prefixRelation(phrase string) (Relation, bool, error) {
+	for p, fn := range relationPrefixes {
+		if strings.HasPrefix(phrase, p) {
+			rl, err := fn(strings.TrimPrefix(phrase, p))
+			return rl, true, err
+		}
+	}
+	for p, fn := range schedulePrefixes {
+		if strings.HasPrefix(phrase, p) {
+			s, err := fn(strings.TrimPrefix(phrase, p))
+			if err != nil {
+				return nil, true, err
+			}
+			return scheduleRelation(s), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// dates is a schedule of the provided occurrences.
+type dates []time.Time
+
+func newDates(ts ...time.Time) dates {
+	ret := append(dates{}, ts...)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Before(ret[j]) })
+	return ret
+}
+
+func (d dates) next(t time.Time) (time.Time, bool) {
+	i := sort.Search(len(d), func(i int) bool { return !d[i].Before(t) })
+	if i < len(d) {
+		return d[i], true
+	}
+	return time.Time{}, false
+}
+
+func (d dates) prev(t time.Time) (time.Time, bool) {
+	i := sort.Search(len(d), func(i int) bool { return !d[i].Before(t) })
+	if i > 0 {
+		return d[i-1], true
+	}
+	return time.Time{}, false
+}
+
+// union is a schedule of the occurrences of all the provided schedules.
+type union []schedule
+
+func (u union) next(t time.Time) (time.Time, bool) {
+	var ret time.Time
+	var found bool
+	for _, v := range u {
+		if o, ok := v.next(t); ok && (!found || o.Before(ret)) {
+			ret, found = o, true
+		}
+	}
+	return ret, found
+}
+
+func (u union) prev(t time.Time) (time.Time, bool) {
+	var ret time.Time
+	var found bool
+	for _, v := range u {
+		if o, ok := v.prev(t); ok && (!found || o.After(ret)) {
+			ret, found = o, true
+		}
+	}
+	return ret, found
+}
+
+// except is a schedule of the occurrences of a schedule less the provided
+// exceptions.
+type except struct {
+	schedule
+	ex dates
+}
+
+func (e except) excluded(t time.Time) bool {
+	o, ok := e.ex.next(t)
+	return ok && o.Equal(t)
+}
+
+func (e except) next(t time.Time) (time.Time, bool) {
+	o, ok := e.schedule.next(t)
+	for ok && e.excluded(o) {
+		o, ok = e.schedule.next(o.Add(time.Nanosecond))
+	}
+	return o, ok
+}
+
+func (e except) prev(t time.Time) (time.Time, bool) {
+	o, ok := e.schedule.prev(t)
+	for ok && e.excluded(o) {
+		o, ok = e.schedule.prev(o)
+	}
+	return o, ok
+}
@@ -1,17 +1,39 @@
 package tart
 
 import (
+	"sync"
 	"time"
+
+	"github.com/araddon/dateparse"
 )
 
 // Tart is a a struct encapsulating functionality related to a specific,
 // embedded time.Time instance. An acronym for "time and relative time(in
 // time)".
+//
+// A Tart instance is safe for concurrent use, with Establish excluding
+// evaluation while the instance time changes.
 type Tart struct {
 	time.Time
 	*relations
 	*directives
-	tFmt string
+	mu        sync.RWMutex
+	clock     Clock
+	tFmt      string
+	monthEnd  MonthEnd
+	clockMode ClockMode
+	localTime LocalTime
+}
+
+// Clock is the source of the current time for a Tart instance.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
 }
 
 // New builds a new Tart instance from the provided Config.
@@ -31,15 +53,29 @@ type Config func(*Tart) error
 
 func mkConfig(cnf ...Config) []Config {
 	def := []Config{
-		func(t *Tart) error { t.Time = time.Now(); return nil },
+		func(t *Tart) error { t.clock = systemClock{}; return nil },
+		func(t *Tart) error { t.Time = t.clock.Now(); return nil },
 		func(t *Tart) error { t.relations = newRelations(t); return nil },
 		func(t *Tart) error { t.directives = newDirectives(); return nil },
 		func(t *Tart) error { t.tFmt = time.RFC3339; return nil },
+		func(t *Tart) error { t.monthEnd = MonthOverflow; return nil },
+		func(t *Tart) error { t.clockMode = ClockAbsolute; return nil },
+		func(t *Tart) error { t.localTime = LocalCompatible; return nil },
 	}
 	def = append(def, cnf...)
 	return def
 }
 
+// WithClock sets the Clock of the instance, and the time of the instance to
+// the current time of that Clock.
+func WithClock(c Clock) Config {
+	return func(t *Tart) error {
+		t.clock = c
+		t.Time = c.Now()
+		return nil
+	}
+}
+
 // SetTimeFmt ...
 func SetTimeFmt(n string) Config {
 	return func(t *Tart) error {
@@ -50,25 +86,73 @@ func SetTimeFmt(n string) Config {
 
 // Establish sets the time of the instance to the provided time. This forces a reset to
 // align the instance to the new time setting all relative funcs to defaults,
-// removing cached time funcs, and erasing any set associations.
+// removing cached time funcs, and erasing any set associations. Use Rebase to
+// keep set associations.
 func (t *Tart) Establish(tt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.Time = tt
 	t.reset()
 }
 
+// Rebase sets the time of the instance to the provided time, keeping all set
+// relations and removing only cached time funcs.
+func (t *Tart) Rebase(tt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Time = tt
+	t.relations.flush()
+}
+
+// Clone returns a new Tart instance at the time of the instance, sharing the
+// relations set on the instance. Relations set on either instance after
+// cloning are not seen by the other.
+func (t *Tart) Clone() *Tart {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.at(t.Time)
+}
+
+// At returns a new Tart instance at the provided time, sharing the relations
+// set on the instance as Clone does.
+func (t *Tart) At(tt time.Time) *Tart {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.at(tt)
+}
+
+func (t *Tart) at(tt time.Time) *Tart {
+	n := &Tart{
+		Time:       tt,
+		directives: newDirectives(),
+		clock:      t.clock,
+		tFmt:       t.tFmt,
+		monthEnd:   t.monthEnd,
+		clockMode:  t.clockMode,
+		localTime:  t.localTime,
+	}
+	n.relations = t.relations.clone(n)
+	return n
+}
+
 func (t *Tart) reset() {
 	t.relations.reset(t)
 	t.directives.reset()
 }
 
-// Set ...
+// Set stores the time of the provided directive as relation k, recurring as
+// the point of the directive does.
 func (t *Tart) Set(k, v string) error {
 	if !isReservedKey(t.relations.rk, k) {
-		d := parse(v)
-		t.setDirective(v, d)
-		nt := pop(t)
-		err := t.SetRelation(k, wrapRelative(nt))
-		return err
+		nt, err := t.GetE(v)
+		if err != nil {
+			return err
+		}
+		var rl Relation = wrapRelative(nt)
+		if rc, ok := t.GetRelation(t.directive(v).phrase).(Recurrent); ok {
+			rl = newRecurrent(rl, rc.Step)
+		}
+		return t.SetRelation(k, rl)
 	}
 	return reservedKeyError(k)
 }
@@ -83,8 +167,28 @@ func (t *Tart) Set(k, v string) error {
 //  '+' = iter next
 //  '-' = iter last
 //
-//  'iter' and 'shift' are functionally equivalent currently, use depending on
-//   your preference and need
+//  'shift' moves by the duration given. 'iter' steps through occurrences of
+//   the point, where the point recurs (weekdays, months, holidays, etc): '+' is
+//   the next occurrence, '++' the one after, '-' the occurrence before. Given a
+//   duration and no shift to carry it, 'iter' moves by that duration as 'shift'
+//   does, and where the point does not recur bare 'iter' is without effect.
+//  '~' = nearest, iterate from the occurrence of the point nearest the tart
+//        instance time rather than the next occurrence
+//
+// Durations are those of time.ParseDuration along with 'd'/'day(s)',
+// 'w'/'week(s)', 'mo'/'month(s)', 'y'/'year(s)' and 'bd'/'bday(s)', business
+// days, skipping weekends and the holidays set on the instance, or an ISO 8601
+// duration, e.g. ">P1DT2H", as ParsePeriod. Days and longer keep the wall
+// clock across daylight saving changes; clock units move by elapsed time, or
+// the wall clock as set by WithClockMode, and prefixed 'w' ('wh', 'wm', 'ws')
+// always the wall clock, prefixed 'a' ('ah', 'am', 'as') always elapsed time.
+// A shift landing on a local time skipped or repeated by a daylight saving
+// change is resolved as set by WithLocalTime.
+//
+// Options, '@' and a word ahead of or among the modifiers, choose how the
+// directive shifts: "@overflow", "@clamp" and "@eom" the MonthEnd policy of
+// shifts by months and years, e.g. "@clamp>1mo!" a month on, no further than
+// the end of the month.
 //
 // Modifiers stack. Modifiers are collected by type. Duration is applied left wise to
 // freestanding modifiers taking duration information.
@@ -102,6 +206,16 @@ func (t *Tart) Set(k, v string) error {
 // of a statement of point with no modification. A null string is equivalent to
 // single point("" == "!")
 //
+// Everything following '!' is the point, signs included, so dates such as
+// "!2019-07-04" need no escaping. A point may also be double quoted, with the
+// escapes of a Go string literal, e.g. `!"\"quoted\" point"`. A recurrent point
+// prefixed "last:" is the latest occurrence before the tart instance time
+// rather than the next, e.g. "!last:monday". A point prefixed "cron:" is the
+// cron expression following, as SetCron, e.g. "!cron:0 9 * * MON", and one
+// prefixed "oncalendar:" the systemd calendar event following, as
+// SetOnCalendar, e.g. "!oncalendar:Mon..Fri 09:00". A point "q1" through "q4",
+// or "quarter:1" through "quarter:4", is that quarter of the year, e.g. "!q3".
+//
 //	e.g.'
 //	   "!july 4 1776"     = time of july 4, 1776
 //     "!tuesday"         = next tuesday
@@ -122,12 +236,15 @@ func (t *Tart) Set(k, v string) error {
 //      `->>1h!tuesday`                = 2 hours forward from last tuesday relative to the tart instance time
 //      `++<<1h!tuesday`               = 2nd tuesday from now shifted back 2 hours
 //      `>>>>>1y!`                     = 5 years from now (where now is tart instance time)
-//      `+++++!`                       = 5 years from now (where now is tart instance time)
 //      `>>>>>1y!tuesday`              = 5 years from next tuesday
 //      `<<<<<1y!oct 31 2025`          = now, if today is oct 31 2020
 //		`>.tuesday`, `!tuesday`        = next tuesday, relative to the tart instance time
-//      `<!tuesday`                    = last tuesday, relative to the tart instance time
-//      `>>>1w!tuesday`, `>>>!tuesday` = 3rd tuesday from tart instance time
+//      `-!tuesday`                    = last tuesday, relative to the tart instance time
+//      `>>>1w!tuesday`, `++++!tuesday`= 4th tuesday from tart instance time
+//      `--!christmas`                 = two christmases ago
+//      `+++!eom`                      = the 3rd end of month from the tart instance time
+//      `!last:soq`                    = the most recent start of quarter
+//      `~!tuesday`                    = whichever tuesday is nearest the tart instance time
 //      `<<<!july 4 2006`              = july 4th 2003
 //      `+!july 4`, `!july 4`          = the next july 4th
 //      `+!christmas`, `!christmas`    = the next christmas (where christmas is defined on the tart instance)
@@ -136,20 +253,63 @@ func (t *Tart) Set(k, v string) error {
 //      `tomorrow`,`!tomorrow`         = time tomorrow, relative to today
 //
 // Unique directives are stored by key and reused within the scope of use.
+//
+// Get never fails; a malformed directive yields whatever time could be
+// salvaged from it. Use GetE to be told about the malformation.
 func (t *Tart) Get(in string) time.Time {
-	var d *directive
-	d = t.getDirective(in)
+	ret, _ := t.GetE(in)
+	return ret
+}
+
+// GetE is Get, returning a *ParseError for a directive with malformed
+// modifiers or a point that is neither a relation nor a parseable date.
+func (t *Tart) GetE(in string) (time.Time, error) {
+	d := t.directive(in)
+	if d.err != nil {
+		ret, _ := t.eval(d)
+		return ret, d.err
+	}
+	return d.Eval(t)
+}
+
+func (t *Tart) directive(in string) *Directive {
+	d := t.getDirective(in)
 	if d != nil {
-		return pop(t)
+		return d
 	}
 	d = parse(in)
 	t.setDirective(in, d)
-	return pop(t)
+	return d
 }
 
-func pop(t *Tart) time.Time {
-	fn := t.popTimeFn()
-	return fn()
+func (t *Tart) checkPoint(c *Context) error {
+	d := c.Directive
+	if t.GetRelation(d.phrase) != nil || quarterRelation(d.phrase) != nil {
+		return nil
+	}
+	if _, ok, err := prefixRelation(d.phrase); ok {
+		if err != nil {
+			return &ParseError{d.origin, d.phraseAt, d.phrase, err.Error()}
+		}
+		return nil
+	}
+	if _, err := dateparse.ParseIn(d.phrase, c.Location()); err != nil {
+		return unknownPointError(d)
+	}
+	return nil
+}
+
+// eval returns the time of the provided directive relative to the instance
+// time, and an error when the point of the directive does not resolve.
+func (t *Tart) eval(d *Directive) (time.Time, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	c := &Context{Time: t.Time, Tart: t, Directive: d}
+	fn := t.popTimeFn(c)
+	if c.err != nil {
+		return fn(), c.err
+	}
+	return fn(), t.checkPoint(c)
 }
 
 // Duration returns the duration of the modifier of a parsed directive in
@@ -160,19 +320,27 @@ func pop(t *Tart) time.Time {
 //      "505h" ==
 //      (time.Duration) 505h0m0s
 func (t *Tart) Duration(in string) time.Duration {
-	var d *directive
-	d = t.getDirective(in)
-	if d != nil {
-		return pumpDur(t.Time, d)
+	ret, _ := t.DurationE(in)
+	return ret
+}
+
+// DurationE is Duration, returning a *ParseError for a directive with
+// malformed modifiers.
+func (t *Tart) DurationE(in string) (time.Duration, error) {
+	d := t.directive(in)
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	c := &Context{Time: t.Time, Tart: t, Directive: d}
+	ret := pumpDur(c)
+	if d.err != nil {
+		return ret, d.err
 	}
-	d = parse(in)
-	t.setDirective(in, d)
-	return pumpDur(t.Time, d)
+	return ret, c.err
 }
 
-func pumpDur(t time.Time, d *directive) time.Duration {
-	var nt time.Time = t
-	nt = pumpShift(nt, d)
+func pumpDur(c *Context) time.Duration {
+	t := c.Time
+	nt := c.Shift(t)
 	nts := nt.Sub(t)
 	if nts < 0 {
 		nts = -nts
@@ -2,8 +2,11 @@ package tart
 
 import (
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/1xch/tart/tarttest"
 )
 
 func TestTart(t *testing.T) {
@@ -19,17 +22,17 @@ type tTart struct {
 	currYear  int
 }
 
+var _ Clock = (*tarttest.Clock)(nil)
+
 func initialize(t *testing.T) *tTart {
-	tartInstance, iErr := New(SetTimeFmt(time.RFC3339), HolidaysBase)
+	clock := tarttest.NewClock(time.Date(2020, time.January, 2, 9, 0, 0, 0, time.Local))
+	tartInstance, iErr := New(SetTimeFmt(time.RFC3339), HolidaysBase, WithClock(clock))
 	if iErr != nil {
 		t.Error(iErr.Error())
 	}
 	testTimeExact := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
-	tartInstance.Establish(testTimeExact)
-	if rErr := tartInstance.SetBatch(holidaysBase(tartInstance)); rErr != nil {
-		t.Error(rErr.Error())
-	}
-	currYear := time.Now().Year()
+	tartInstance.Rebase(testTimeExact)
+	currYear := testTimeExact.Year()
 	return &tTart{tartInstance, testTimeExact, currYear}
 }
 
@@ -137,7 +140,7 @@ func testSet(t *testing.T, tt *tTart) {
 			"[SETRELATION 'check SETRELATION reservedKeyError']",
 			nilTime,
 			reservedKeyError("any"),
-			func(x *Tart) error { return x.SetRelation("any", newRelation(func(*Tart) TimeFunc { return nil })) },
+			func(x *Tart) error { return x.SetRelation("any", newRelation(func(*Context) TimeFunc { return nil })) },
 			nil,
 		},
 		{
@@ -177,6 +180,12 @@ func testSet(t *testing.T, tt *tTart) {
 			}
 		}
 	}
+	if err := tt.Set("payday", "<1d!som"); err != nil {
+		t.Error(err.Error())
+	}
+	if pd, exp := tt.Get("++!payday"), time.Date(2019, time.August, 31, 0, 0, 0, 0, time.Local); !pd.Equal(exp) {
+		t.Errorf("[SET 'payday'=='<1d!som'] '++!payday' expected %v, but got %v", exp, pd)
+	}
 	for _, v := range testSet {
 		if v.gfn != nil && v.exp != nilTime {
 			cmp := v.gfn(tt.Tart)
@@ -223,6 +232,9 @@ func testGet(t *testing.T, tt *tTart) {
 		{"!July 4th 2019 at 11:00PM", time.Date(2019, time.July, 4, 23, 0, 0, 0, time.Local)},
 		{"!july 4th 2099", time.Date(2099, time.July, 4, 0, 0, 0, 0, time.Local)},
 		{"!july 4", time.Date(tt.currYear, time.July, 4, 0, 0, 0, 0, time.Local)},
+		{"!2019-07-04", time.Date(2019, time.July, 4, 0, 0, 0, 0, time.Local)},
+		{">1d!2019-07-04T10:00:00-04:00", time.Date(2019, time.July, 5, 14, 0, 0, 0, time.UTC)},
+		{"<1h!\"2019-07-04 10:00\"", time.Date(2019, time.July, 4, 9, 0, 0, 0, time.Local)},
 		{"!yesterday", time.Date(2019, time.July, 3, 0, 0, 0, 0, time.Local)},
 		{"+1d!yesterday", time.Date(2019, time.July, 4, 0, 0, 0, 0, time.Local)},
 		{"++1d!yesterday", time.Date(2019, time.July, 5, 0, 0, 0, 0, time.Local)},
@@ -237,6 +249,30 @@ func testGet(t *testing.T, tt *tTart) {
 		{"-1w!tuesday", time.Date(2019, time.July, 2, 0, 0, 0, 0, time.Local)},
 		{">1w!tuesday", time.Date(2019, time.July, 16, 0, 0, 0, 0, time.Local)},
 		{"!christmas", time.Date(2019, time.December, 25, 12, 0, 0, 0, time.Local)},
+		// iteration through occurrences
+		{"+!tuesday", time.Date(2019, time.July, 9, 0, 0, 0, 0, time.Local)},
+		{"++!tuesday", time.Date(2019, time.July, 16, 0, 0, 0, 0, time.Local)},
+		{"-!tuesday", time.Date(2019, time.July, 2, 0, 0, 0, 0, time.Local)},
+		{"++<<1h!tuesday", time.Date(2019, time.July, 15, 22, 0, 0, 0, time.Local)},
+		{"->>1h!tuesday", time.Date(2019, time.July, 2, 2, 0, 0, 0, time.Local)},
+		{"--!christmas", time.Date(2017, time.December, 25, 12, 0, 0, 0, time.Local)},
+		{"+++!eom", time.Date(2019, time.September, 30, 23, 59, 59, 0, time.Local)},
+		{"--!eoq", time.Date(2019, time.March, 31, 23, 59, 59, 59, time.Local)},
+		{"-!june", time.Date(2019, time.June, 1, 0, 0, 0, 0, time.Local)},
+		{"+-+!soy", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)},
+		{"+++!", tt.timeExact},
+		// previous & nearest occurrences
+		{"!last:tuesday", time.Date(2019, time.July, 2, 0, 0, 0, 0, time.Local)},
+		{"last:thursday", time.Date(2019, time.July, 4, 0, 0, 0, 0, time.Local)},
+		{"-!last:monday", time.Date(2019, time.June, 24, 0, 0, 0, 0, time.Local)},
+		{">1h!last:monday", time.Date(2019, time.July, 1, 1, 0, 0, 0, time.Local)},
+		{"!last:soq", time.Date(2019, time.July, 1, 0, 0, 0, 0, time.Local)},
+		{"!last:december", time.Date(2018, time.December, 1, 0, 0, 0, 0, time.Local)},
+		{"!last:christmas", time.Date(2018, time.December, 25, 12, 0, 0, 0, time.Local)},
+		{"~!tuesday", time.Date(2019, time.July, 2, 0, 0, 0, 0, time.Local)},
+		{"~!saturday", time.Date(2019, time.July, 6, 0, 0, 0, 0, time.Local)},
+		{"~!january", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)},
+		{"~!christmas", time.Date(2019, time.December, 25, 12, 0, 0, 0, time.Local)},
 		// week
 		{"!sow", time.Date(2019, time.July, 7, 0, 0, 0, 0, time.Local)},
 		{"sunday", time.Date(2019, time.July, 7, 0, 0, 0, 0, time.Local)},
@@ -305,6 +341,1194 @@ func testDuration(t *testing.T, tt *tTart) {
 	}
 }
 
+type directiveCase struct {
+	req   string
+	exp   time.Time
+	fails bool
+}
+
+func anchored(t *testing.T, anchor time.Time, cnf ...Config) *Tart {
+	t.Helper()
+	ti, err := New(append([]Config{WithClock(tarttest.NewClock(anchor))}, cnf...)...)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return ti
+}
+
+func testDirectives(t *testing.T, ti *Tart, cases []directiveCase) {
+	t.Helper()
+	for _, v := range cases {
+		got, err := ti.GetE(v.req)
+		if (err != nil) != v.fails || (!v.exp.IsZero() && !got.Equal(v.exp)) {
+			t.Errorf("%s from %v expected %v, failing %t, but got %v, error %v", strings.ToUpper(v.req), ti.Time, v.exp, v.fails, got, err)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tt := initialize(t)
+	var pe = []struct {
+		in  string
+		exp string
+	}{
+		{">3x!tuesday", `">3x!tuesday": unknown unit "x" at 1`},
+		{"<<1d>2fortnights", `"<<1d>2fortnights": unknown unit "fortnights" at 5`},
+		{">3!now", `">3!now": missing unit "3" at 1`},
+		{">1h!notaday", `">1h!notaday": unknown point "notaday" at 4`},
+		{"notaday", `"notaday": unknown point "notaday" at 0`},
+		{`>1h!"eod`, `">1h!\"eod": unterminated quote "\"eod" at 4`},
+		{"~!last:tuesday", `"~!last:tuesday": conflicting selector "last:" at 2`},
+		{`!"eod"x`, `"!\"eod\"x": unexpected text after quote "x" at 6`},
+	}
+	for _, v := range pe {
+		_, err := tt.GetE(v.in)
+		if err == nil {
+			t.Errorf("%s: expected error '%s' but got none", v.in, v.exp)
+			continue
+		}
+		if _, ok := err.(*ParseError); !ok {
+			t.Errorf("%s: expected *ParseError but got %T", v.in, err)
+		}
+		if err.Error() != v.exp {
+			t.Errorf("expected error '%s' but got error '%s'", v.exp, err)
+		}
+	}
+	if _, err := tt.DurationE(">1h>3x"); err == nil {
+		t.Error("DurationE '>1h>3x': expected error but got none")
+	}
+	for _, v := range []string{">1.5h!eod", ">1h30m", "!july 4 2019", "<<1d!tuesday"} {
+		if _, err := tt.GetE(v); err != nil {
+			t.Errorf("%s: unexpected error %s", v, err)
+		}
+	}
+	if err := tt.Set("typo", ">3x!tuesday"); err == nil {
+		t.Error("SET 'typo'=='>3x!tuesday': expected error but got none")
+	}
+}
+
+// roundTrips reports whether the canonical form of d compiles to the same
+// directive.
+func roundTrips(d *Directive) bool {
+	rd, err := Compile(d.String())
+	return err == nil && rd.String() == d.String() && rd.Point() == d.Point() && rd.sel == d.sel
+}
+
+func TestCompile(t *testing.T) {
+	tt := initialize(t)
+	var cmp = []struct {
+		in, canon string
+		mods      []string
+	}{
+		{"", "!now", []string{}},
+		{"tuesday", "!tuesday", []string{}},
+		{">>1w", ">>1w!now", []string{">>1w"}},
+		{"<1d<2d<<<<3d!eod", "<1d<2d<<<<3d!eod", []string{"<1d", "<2d", "<<<<3d"}},
+		{"++<<1h!tuesday", "++<<1h!tuesday", []string{"++<<1h"}},
+		{">1d!2019-07-04", ">1d!2019-07-04", []string{">1d"}},
+		{`>1d!"eod"`, ">1d!eod", []string{">1d"}},
+		{"last:tuesday", "!last:tuesday", []string{}},
+		{"cron:0 9 * * 1", `!"cron:0 9 * * 1"`, []string{}},
+		{"~<1h!tuesday", "~<1h!tuesday", []string{"~<1h"}},
+	}
+	for _, v := range cmp {
+		d, err := Compile(v.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", v.in, err)
+			continue
+		}
+		if s := d.String(); s != v.canon {
+			t.Errorf("%s: expected canonical form %s, but got %s", v.in, v.canon, s)
+		}
+		if m := d.Modifiers(); strings.Join(m, ",") != strings.Join(v.mods, ",") {
+			t.Errorf("%s: expected modifiers %v, but got %v", v.in, v.mods, m)
+		}
+		if !roundTrips(d) {
+			t.Errorf("%s: canonical form %s does not round trip", v.in, d)
+		}
+		exp := tt.Get(v.in)
+		got, err := d.Eval(tt.Tart)
+		if err != nil || !got.Equal(exp) {
+			t.Errorf("%s: expected %v, but got %v (%v)", v.in, exp, got, err)
+		}
+		got, err = d.EvalAt(tt.timeExact)
+		if err != nil || !got.Equal(exp) {
+			t.Errorf("%s: EvalAt expected %v, but got %v (%v)", v.in, exp, got, err)
+		}
+	}
+	for _, v := range []string{`!"last:monday"`, `>1d!""`, `!"cron:0 9 * * 1"`, `!"oncalendar:weekly"`, `!"quarter:3"`} {
+		if d, err := Compile(v); err != nil || d.String() != v || !roundTrips(d) {
+			t.Errorf("%s: expected to round trip, but got %v (%v)", v, d, err)
+		}
+	}
+	if _, err := Compile(">3x!tuesday"); err == nil {
+		t.Error("compile '>3x!tuesday': expected error but got none")
+	}
+	d, _ := Compile("!christmas")
+	if _, err := d.EvalAt(tt.timeExact); err == nil {
+		t.Error("EvalAt '!christmas': expected unknown point error but got none")
+	}
+}
+
+func TestClock(t *testing.T) {
+	c := tarttest.NewClock(time.Date(2031, time.January, 1, 0, 0, 0, 0, time.Local))
+	ti, err := New(WithClock(c))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !ti.Time.Equal(c.Now()) {
+		t.Errorf("expected instance time %v, but got %v", c.Now(), ti.Time)
+	}
+	c.Set(time.Date(2032, time.December, 31, 0, 0, 0, 0, time.Local))
+	exp := time.Date(2031, time.July, 4, 0, 0, 0, 0, time.Local)
+	if got := ti.Get("!july 4"); !got.Equal(exp) {
+		t.Errorf("!JULY 4 expected %v, but got %v", exp, got)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	tt := initialize(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got, exp := tt.Get("+1d!tuesday"), time.Date(2019, time.July, 10, 0, 0, 0, 0, time.Local); !got.Equal(exp) {
+					t.Errorf("+1D!TUESDAY expected %v, but got %v", exp, got)
+				}
+				tt.Get(">1h!eod")
+				tt.Duration(">7d")
+				tt.SetDirect("concurrent", tt.timeExact)
+				tt.Get("concurrent")
+				tt.Rebase(tt.timeExact)
+				tt.At(tt.timeExact).Get("!tuesday")
+				for range tt.Occurrences(">1bd!tuesday", tt.timeExact, tt.timeExact.AddDate(0, 1, 0)) {
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRebase(t *testing.T) {
+	tt := initialize(t)
+	if err := tt.SetDirect("launch", time.Date(2019, time.July, 16, 13, 32, 0, 0, time.Local)); err != nil {
+		t.Fatal(err.Error())
+	}
+	tt.Get("!christmas")
+	tt.Rebase(time.Date(2019, time.December, 26, 0, 0, 0, 0, time.Local))
+	testDirectives(t, tt.Tart, []directiveCase{
+		{"!last:christmas", time.Date(2019, time.December, 25, 12, 0, 0, 0, time.Local), false},
+		{"!launch", time.Date(2019, time.July, 16, 13, 32, 0, 0, time.Local), false},
+	})
+
+	at := tt.At(time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local))
+	testDirectives(t, at, []directiveCase{{"!tuesday", time.Date(2019, time.July, 9, 0, 0, 0, 0, time.Local), false}})
+	testDirectives(t, tt.Tart, []directiveCase{{"!tuesday", time.Date(2019, time.December, 31, 0, 0, 0, 0, time.Local), false}})
+	if err := at.SetDirect("landing", time.Date(2019, time.July, 20, 20, 17, 0, 0, time.Local)); err != nil {
+		t.Fatal(err.Error())
+	}
+	testDirectives(t, tt.Tart, []directiveCase{{"!landing", nilTime, true}})
+
+	cl := tt.Clone()
+	if !cl.Time.Equal(tt.Time) || !cl.Get("!launch").Equal(tt.Get("!launch")) {
+		t.Errorf("clone differs from original: %v, %v", cl.Time, cl.Get("!launch"))
+	}
+
+	tt.Establish(tt.timeExact)
+	testDirectives(t, tt.Tart, []directiveCase{{"!launch", nilTime, true}})
+}
+
+func TestBusinessDays(t *testing.T) {
+	tt := initialize(t)
+	var isbd = []struct {
+		day time.Time
+		exp bool
+	}{
+		{time.Date(2019, time.July, 5, 15, 0, 0, 0, time.Local), true},
+		{time.Date(2019, time.July, 6, 0, 0, 0, 0, time.Local), false},
+		{time.Date(2019, time.July, 7, 0, 0, 0, 0, time.Local), false},
+		{time.Date(2019, time.December, 25, 9, 0, 0, 0, time.Local), false},
+		{time.Date(2021, time.December, 24, 9, 0, 0, 0, time.Local), true},
+	}
+	for _, v := range isbd {
+		if got := tt.IsBusinessDay(v.day); got != v.exp {
+			t.Errorf("IsBusinessDay(%v) expected %t, but got %t", v.day, v.exp, got)
+		}
+	}
+	var add = []struct {
+		from time.Time
+		n    int
+		exp  time.Time
+	}{
+		{time.Date(2019, time.December, 24, 9, 0, 0, 0, time.Local), 1, time.Date(2019, time.December, 26, 9, 0, 0, 0, time.Local)},
+		{time.Date(2019, time.December, 26, 9, 0, 0, 0, time.Local), -1, time.Date(2019, time.December, 24, 9, 0, 0, 0, time.Local)},
+		{time.Date(2019, time.July, 5, 9, 0, 0, 0, time.Local), 1, time.Date(2019, time.July, 8, 9, 0, 0, 0, time.Local)},
+		{time.Date(2019, time.July, 5, 9, 0, 0, 0, time.Local), 0, time.Date(2019, time.July, 5, 9, 0, 0, 0, time.Local)},
+	}
+	for _, v := range add {
+		if got := tt.AddBusinessDays(v.from, v.n); !got.Equal(v.exp) {
+			t.Errorf("AddBusinessDays(%v, %d) expected %v, but got %v", v.from, v.n, v.exp, got)
+		}
+	}
+	a, b := time.Date(2019, time.December, 20, 0, 0, 0, 0, time.Local), time.Date(2019, time.December, 30, 0, 0, 0, 0, time.Local)
+	if n := tt.BusinessDaysBetween(a, b); n != 5 {
+		t.Errorf("BusinessDaysBetween(%v, %v) expected 5, but got %d", a, b, n)
+	}
+	if n := tt.BusinessDaysBetween(b, a); n != -5 {
+		t.Errorf("BusinessDaysBetween(%v, %v) expected -5, but got %d", b, a, n)
+	}
+	b = time.Date(2021, time.December, 30, 0, 0, 0, 0, time.Local)
+	if n := tt.BusinessDaysBetween(a, b); n != 527 {
+		t.Errorf("BusinessDaysBetween(%v, %v) expected 527, but got %d", a, b, n)
+	}
+	testDirectives(t, tt.Tart, []directiveCase{
+		{">5bd!now", time.Date(2019, time.July, 11, 12, 0, 0, 0, time.Local), false},
+		{">>1bd", time.Date(2019, time.July, 8, 12, 0, 0, 0, time.Local), false},
+		{"<1bd!monday", time.Date(2019, time.July, 5, 0, 0, 0, 0, time.Local), false},
+		{">1bd!christmas", time.Date(2019, time.December, 26, 12, 0, 0, 0, time.Local), false},
+	})
+	if d := tt.Duration(">5bd"); d != 7*24*time.Hour {
+		t.Errorf("unequal durations: %v != %v", d, 7*24*time.Hour)
+	}
+}
+
+func TestHolidaysUS(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor, HolidaysUS)
+	testDirectives(t, ti, []directiveCase{
+		{"!newyears", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local), false},
+		{"!mlk", time.Date(2020, time.January, 20, 0, 0, 0, 0, time.Local), false},
+		{"!washington", time.Date(2020, time.February, 17, 0, 0, 0, 0, time.Local), false},
+		{"!memorial", time.Date(2020, time.May, 25, 0, 0, 0, 0, time.Local), false},
+		{"!last:memorial", time.Date(2019, time.May, 27, 0, 0, 0, 0, time.Local), false},
+		{"!juneteenth", time.Date(2021, time.June, 19, 0, 0, 0, 0, time.Local), false},
+		{"!juneteenth-observed", time.Date(2021, time.June, 18, 0, 0, 0, 0, time.Local), false},
+		{"!independence", time.Date(2019, time.July, 4, 0, 0, 0, 0, time.Local), false},
+		{"++!independence-observed", time.Date(2020, time.July, 3, 0, 0, 0, 0, time.Local), false},
+		{"!labor", time.Date(2019, time.September, 2, 0, 0, 0, 0, time.Local), false},
+		{"!columbus", time.Date(2019, time.October, 14, 0, 0, 0, 0, time.Local), false},
+		{"!veterans-observed", time.Date(2019, time.November, 11, 0, 0, 0, 0, time.Local), false},
+		{"!thanksgiving", time.Date(2019, time.November, 28, 0, 0, 0, 0, time.Local), false},
+		{"-!thanksgiving", time.Date(2018, time.November, 22, 0, 0, 0, 0, time.Local), false},
+		{"+++!christmas-observed", time.Date(2021, time.December, 24, 0, 0, 0, 0, time.Local), false},
+	})
+	ti.Rebase(time.Date(2021, time.December, 30, 0, 0, 0, 0, time.Local))
+	testDirectives(t, ti, []directiveCase{
+		{"!newyears-observed", time.Date(2021, time.December, 31, 0, 0, 0, 0, time.Local), false},
+		{"++!newyears-observed", time.Date(2023, time.January, 2, 0, 0, 0, 0, time.Local), false},
+	})
+	for _, v := range []time.Time{
+		time.Date(2020, time.July, 3, 9, 0, 0, 0, time.Local),
+		time.Date(2019, time.November, 28, 9, 0, 0, 0, time.Local),
+		time.Date(2021, time.December, 31, 9, 0, 0, 0, time.Local),
+	} {
+		if ti.IsBusinessDay(v) {
+			t.Errorf("IsBusinessDay(%v) expected false, but got true", v)
+		}
+	}
+
+	for _, cnf := range [][]Config{{HolidaysBase, HolidaysUS}, {HolidaysUS, HolidaysBase}} {
+		testDirectives(t, anchored(t, anchor, cnf...), []directiveCase{
+			{"!christmas", time.Date(2019, time.December, 25, 12, 0, 0, 0, time.Local), false},
+		})
+	}
+
+	tt := initialize(t)
+	tt.Rebase(time.Date(2019, time.December, 26, 0, 0, 0, 0, time.Local))
+	testDirectives(t, tt.Tart, []directiveCase{
+		{"!christmas", time.Date(2020, time.December, 25, 12, 0, 0, 0, time.Local), false},
+	})
+}
+
+func TestHolidaysEaster(t *testing.T) {
+	var computus = []struct {
+		y             int
+		western, east time.Time
+	}{
+		{1961, time.Date(1961, time.April, 2, 0, 0, 0, 0, time.UTC), time.Date(1961, time.April, 9, 0, 0, 0, 0, time.UTC)},
+		{2019, time.Date(2019, time.April, 21, 0, 0, 0, 0, time.UTC), time.Date(2019, time.April, 28, 0, 0, 0, 0, time.UTC)},
+		{2020, time.Date(2020, time.April, 12, 0, 0, 0, 0, time.UTC), time.Date(2020, time.April, 19, 0, 0, 0, 0, time.UTC)},
+		{2021, time.Date(2021, time.April, 4, 0, 0, 0, 0, time.UTC), time.Date(2021, time.May, 2, 0, 0, 0, 0, time.UTC)},
+		{2024, time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, time.May, 5, 0, 0, 0, 0, time.UTC)},
+		{2038, time.Date(2038, time.April, 25, 0, 0, 0, 0, time.UTC), time.Date(2038, time.April, 25, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, v := range computus {
+		if w, _ := easter(v.y, time.UTC); !w.Equal(v.western) {
+			t.Errorf("easter %d expected %v, but got %v", v.y, v.western, w)
+		}
+		if e, _ := orthodoxEaster(v.y, time.UTC); !e.Equal(v.east) {
+			t.Errorf("orthodox easter %d expected %v, but got %v", v.y, v.east, e)
+		}
+	}
+
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor, HolidaysEaster)
+	if err := ti.SetRelation("karfreitag", AsHoliday(FromEaster(-2))); err != nil {
+		t.Fatal(err.Error())
+	}
+	testDirectives(t, ti, []directiveCase{
+		{"!easter", time.Date(2020, time.April, 12, 0, 0, 0, 0, time.Local), false},
+		{"!last:easter", time.Date(2019, time.April, 21, 0, 0, 0, 0, time.Local), false},
+		{"!good-friday", time.Date(2020, time.April, 10, 0, 0, 0, 0, time.Local), false},
+		{"!ascension", time.Date(2020, time.May, 21, 0, 0, 0, 0, time.Local), false},
+		{"!pentecost", time.Date(2020, time.May, 31, 0, 0, 0, 0, time.Local), false},
+		{"++!orthodox-easter", time.Date(2021, time.May, 2, 0, 0, 0, 0, time.Local), false},
+		{">9h!last:karfreitag", time.Date(2019, time.April, 19, 9, 0, 0, 0, time.Local), false},
+	})
+	if ti.IsBusinessDay(time.Date(2020, time.April, 10, 0, 0, 0, 0, time.Local)) {
+		t.Error("karfreitag 2020 expected not a business day")
+	}
+}
+
+func TestHolidaysDE(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	states := make(map[string]*Tart)
+	for _, v := range []string{"BY", "BE", "SN", "HB"} {
+		ti := anchored(t, anchor, HolidaysDE(v))
+		states[v] = ti
+	}
+	var de = []struct {
+		state, req string
+		exp        time.Time
+	}{
+		{"BY", "!fronleichnam", time.Date(2020, time.June, 11, 0, 0, 0, 0, time.Local)},
+		{"BY", "!heilige-drei-koenige", time.Date(2020, time.January, 6, 0, 0, 0, 0, time.Local)},
+		{"BY", "!last:pfingstmontag", time.Date(2019, time.June, 10, 0, 0, 0, 0, time.Local)},
+		{"BE", "!frauentag", time.Date(2020, time.March, 8, 0, 0, 0, 0, time.Local)},
+		{"BE", "!tag-der-deutschen-einheit", time.Date(2019, time.October, 3, 0, 0, 0, 0, time.Local)},
+		{"SN", "!buss-und-bettag", time.Date(2019, time.November, 20, 0, 0, 0, 0, time.Local)},
+		{"SN", "+!buss-und-bettag", time.Date(2019, time.November, 20, 0, 0, 0, 0, time.Local)},
+		{"SN", "++!buss-und-bettag", time.Date(2020, time.November, 18, 0, 0, 0, 0, time.Local)},
+		{"SN", "!reformationstag", time.Date(2019, time.October, 31, 0, 0, 0, 0, time.Local)},
+		{"HB", "-!reformationstag", time.Date(2018, time.October, 31, 0, 0, 0, 0, time.Local)},
+	}
+	for _, v := range de {
+		if got := states[v.state].Get(v.req); !got.Equal(v.exp) {
+			t.Errorf("%s %s expected %v, but got %v", v.state, strings.ToUpper(v.req), v.exp, got)
+		}
+	}
+	testDirectives(t, states["BE"], []directiveCase{{"!fronleichnam", nilTime, true}})
+	reformation := time.Date(2017, time.October, 31, 0, 0, 0, 0, time.Local)
+	testDirectives(t, states["BY"], []directiveCase{
+		{"!frauentag", nilTime, true},
+		{"!reformationstag", nilTime, true},
+	})
+	testDirectives(t, states["BY"].At(time.Date(2017, time.July, 4, 12, 0, 0, 0, time.Local)), []directiveCase{{"!reformationstag", reformation, false}})
+	testDirectives(t, states["HB"], []directiveCase{{"--!reformationstag", reformation, false}})
+	allerheiligen := time.Date(2019, time.November, 1, 9, 0, 0, 0, time.Local)
+	if states["BY"].IsBusinessDay(allerheiligen) || !states["BE"].IsBusinessDay(allerheiligen) {
+		t.Errorf("allerheiligen expected a holiday in BY and a business day in BE")
+	}
+	if _, err := New(HolidaysDE("XX")); err == nil || err.Error() != stateError("XX").Error() {
+		t.Errorf("expected error '%s' but got error '%v'", stateError("XX"), err)
+	}
+}
+
+const testRules = `[
+	{"name": "founders", "month": "march", "day": 14, "holiday": true, "observed": "weekend", "first_year": 2005, "last_year": 2030},
+	{"name": "founders-eve", "offset": {"relation": "founders", "days": -1}},
+	{"name": "summer-friday", "month": 8, "weekday": "friday", "nth": -1, "holiday": true},
+	{"name": "offsite", "month": "september", "weekday": 2, "nth": 2},
+	{"name": "offsite-dinner", "offset": {"relation": "offsite", "days": 1}},
+	{"name": "leap-friday", "month": "february", "weekday": "friday", "nth": 5},
+	{"name": "black-friday", "offset": {"relation": "thanksgiving", "days": 1}, "holiday": true}
+]`
+
+const testYAMLRules = `
+- name: founders
+  month: march
+  day: 14
+  holiday: true
+  observed: weekend
+  first_year: 2005
+  last_year: 2030
+- name: summer-friday
+  month: 8
+  weekday: Friday
+  nth: -1
+  holiday: true
+- name: black-friday
+  offset: {relation: thanksgiving, days: 1}
+  holiday: true
+`
+
+func TestLoadRules(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor, HolidaysUS, LoadRules(strings.NewReader(testRules)))
+	lr := []directiveCase{
+		{"!founders", time.Date(2020, time.March, 14, 0, 0, 0, 0, time.Local), false},
+		{"!founders-observed", time.Date(2020, time.March, 13, 0, 0, 0, 0, time.Local), false},
+		{"-!founders", time.Date(2019, time.March, 14, 0, 0, 0, 0, time.Local), false},
+		{"!summer-friday", time.Date(2019, time.August, 30, 0, 0, 0, 0, time.Local), false},
+		{"!offsite", time.Date(2019, time.September, 10, 0, 0, 0, 0, time.Local), false},
+		{"!offsite-dinner", time.Date(2019, time.September, 11, 0, 0, 0, 0, time.Local), false},
+		{"!leap-friday", time.Date(2036, time.February, 29, 0, 0, 0, 0, time.Local), false},
+		{"-!leap-friday", time.Date(2008, time.February, 29, 0, 0, 0, 0, time.Local), false},
+		{"!black-friday", time.Date(2019, time.November, 29, 0, 0, 0, 0, time.Local), false},
+		{"++!black-friday", time.Date(2020, time.November, 27, 0, 0, 0, 0, time.Local), false},
+	}
+	testDirectives(t, ti, lr)
+	ti.Rebase(time.Date(2031, time.January, 1, 0, 0, 0, 0, time.Local))
+	testDirectives(t, ti, []directiveCase{
+		{"!founders", nilTime, true},
+		{"+!founders-observed", nilTime, true},
+		{"!founders-eve", nilTime, true},
+	})
+	if ti.IsBusinessDay(time.Date(2019, time.November, 29, 9, 0, 0, 0, time.Local)) {
+		t.Error("black friday expected not a business day")
+	}
+
+	var bad = []struct {
+		rules, exp string
+	}{
+		{`[{"name": "x", "month": "smarch", "day": 1}]`, "rule 'x': unknown month 'smarch'"},
+		{`[{"name": "x", "month": 1}]`, "rule 'x': missing day or weekday"},
+		{`[{"name": "x", "month": 1, "weekday": "monday"}]`, "rule 'x': nth weekday must be 1 to 5 or -1 to -5"},
+		{`[{"name": "x", "offset": {"relation": "y", "days": 1}}]`, "rule 'x': offset from 'y', not a recurrent relation"},
+		{`[{"name": "x", "month": 1, "day": 1, "observed": "always"}]`, "rule 'x': unknown observed policy 'always'"},
+		{`[{"month": 1, "day": 1}]`, "rule '': missing name"},
+	}
+	for _, v := range bad {
+		_, err := New(LoadRules(strings.NewReader(v.rules)))
+		if err == nil || err.Error() != v.exp {
+			t.Errorf("expected error '%s' but got error '%v'", v.exp, err)
+		}
+	}
+	if _, err := New(LoadRules(strings.NewReader("{"))); err == nil {
+		t.Error("expected error reading malformed rules but got none")
+	}
+
+	yi := anchored(t, anchor, HolidaysUS, LoadRules(strings.NewReader(testYAMLRules)))
+	var ylr []directiveCase
+	for _, v := range lr {
+		if yi.GetRelation(v.req[strings.Index(v.req, "!")+1:]) != nil {
+			ylr = append(ylr, v)
+		}
+	}
+	testDirectives(t, yi, ylr)
+	if _, err := New(LoadRules(strings.NewReader("- name: x\n  month: [1]\n  day: 1\n"))); err == nil {
+		t.Error("expected error reading malformed YAML rules but got none")
+	}
+}
+
+const testICS = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+SUMMARY:All Hands
+DTSTART;TZID=America/New_York:20190603T100000
+RRULE:FREQ=WEEKLY
+EXDATE;TZID=America/New_York:20190708T100000
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:All Hands
+DTSTART;TZID=America/New_York:20190710T150000
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Launch\, v2
+DTSTART:20190801T160000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Rent
+DTSTART;VALUE=DATE:20190131
+RRULE:FREQ=MONTHLY;COUNT=3
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestICS(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err.Error())
+	}
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, ny)
+	ti := anchored(t, anchor, ImportICS(strings.NewReader(testICS)))
+	testDirectives(t, ti, []directiveCase{
+		{"!all-hands", time.Date(2019, time.July, 10, 15, 0, 0, 0, ny), false},
+		{"++!all-hands", time.Date(2019, time.July, 15, 10, 0, 0, 0, ny), false},
+		{"-!all-hands", time.Date(2019, time.July, 1, 10, 0, 0, 0, ny), false},
+		{">1h!launch-v2", time.Date(2019, time.August, 1, 17, 0, 0, 0, time.UTC), false},
+	})
+	testDirectives(t, ti, []directiveCase{{"!rent", nilTime, true}})
+	ti.Rebase(time.Date(2019, time.February, 1, 0, 0, 0, 0, ny))
+	testDirectives(t, ti, []directiveCase{{"!rent", time.Date(2019, time.March, 31, 0, 0, 0, 0, ny), false}})
+
+	var b strings.Builder
+	ti.Rebase(anchor)
+	if err := ti.ExportICS(&b, map[string]string{"Launch, v2": "!launch-v2", "Follow Up": ">1d!"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, exp := range []string{"DTSTART:20190801T160000Z\r\nSUMMARY:Launch\\, v2\r\n", "DTSTART:20190705T160000Z\r\nSUMMARY:Follow Up\r\n"} {
+		if !strings.Contains(b.String(), exp) {
+			t.Errorf("export expected to contain %q, but got %q", exp, b.String())
+		}
+	}
+	rt := anchored(t, anchor, ImportICS(strings.NewReader(b.String())))
+	if got, exp := rt.Get("!follow-up"), ti.Get(">1d!"); !got.Equal(exp) {
+		t.Errorf("reimported !FOLLOW-UP expected %v, but got %v", exp, got)
+	}
+
+	long := "Quarterly planning, budget review & retrospective — all departments, Building 7 atrium"
+	b.Reset()
+	if err := ti.ExportICS(&b, map[string]string{long: "!launch-v2"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, l := range strings.Split(b.String(), "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("export expected lines of at most 75 octets, but got %d: %q", len(l), l)
+		}
+	}
+	rt = anchored(t, anchor, ImportICS(strings.NewReader(b.String())))
+	if got, exp := rt.Get("!"+icsKey(long)), ti.Get("!launch-v2"); !got.Equal(exp) {
+		t.Errorf("reimported folded summary expected %v, but got %v", exp, got)
+	}
+
+	var reported []error
+	clash := "BEGIN:VEVENT\nSUMMARY:EOM\nDTSTART:20190801T160000Z\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Standup\nDTSTART:20190801T140000Z\nEND:VEVENT\n"
+	ci := anchored(t, anchor, ImportICS(strings.NewReader(clash), func(err error) {
+		reported = append(reported, err)
+	}))
+	if len(reported) != 1 {
+		t.Errorf("expected the reserved key event reported, but got %v", reported)
+	}
+	testDirectives(t, ci, []directiveCase{
+		{"!eom-event", time.Date(2019, time.August, 1, 16, 0, 0, 0, time.UTC), false},
+		{"!standup", time.Date(2019, time.August, 1, 14, 0, 0, 0, time.UTC), false},
+	})
+
+	for _, bad := range []string{
+		"BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nSUMMARY:x\nDTSTART:20190101\nRRULE:FREQ=FORTNIGHTLY\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nSUMMARY:x\nDTSTART;TZID=Mars/Olympus_Mons:20190101T090000\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nSUMMARY:x\nDTSTART:20190101T090000\nEXDATE;TZID=Mars/Olympus_Mons:20190101T090000\nEND:VEVENT\n",
+	} {
+		if _, err := New(ImportICS(strings.NewReader(bad))); err == nil {
+			t.Errorf("expected error importing %q", bad)
+		}
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor)
+	for k, v := range map[string]string{
+		"payday":         "FREQ=MONTHLY;BYMONTHDAY=15,-1",
+		"second-tuesday": "FREQ=MONTHLY;BYDAY=2TU",
+		"sprint-review":  "DTSTART:20190614T170000\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=FR",
+		"last-workday":   "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+		"turkey":         "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH",
+		"twice":          "FREQ=DAILY;COUNT=2",
+		"every-7m":       "DTSTART:20150101T000000Z\nRRULE:FREQ=MINUTELY;INTERVAL=7",
+		"leap-day":       "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29",
+		"leap-minute":    "DTSTART:20190101T000000Z\nRRULE:FREQ=MINUTELY;BYMONTH=2;BYMONTHDAY=29",
+	} {
+		if err := ti.SetRecurrence(k, v); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	testDirectives(t, ti, []directiveCase{
+		{"!payday", time.Date(2019, time.July, 15, 0, 0, 0, 0, time.Local), false},
+		{"++!payday", time.Date(2019, time.July, 31, 0, 0, 0, 0, time.Local), false},
+		{"+++!payday", time.Date(2019, time.August, 15, 0, 0, 0, 0, time.Local), false},
+		{"-!payday", time.Date(2019, time.June, 30, 0, 0, 0, 0, time.Local), false},
+		{"!last:payday", time.Date(2019, time.June, 30, 0, 0, 0, 0, time.Local), false},
+		{"!second-tuesday", time.Date(2019, time.July, 9, 0, 0, 0, 0, time.Local), false},
+		{"++!second-tuesday", time.Date(2019, time.August, 13, 0, 0, 0, 0, time.Local), false},
+		{"!sprint-review", time.Date(2019, time.July, 12, 17, 0, 0, 0, time.Local), false},
+		{"-!sprint-review", time.Date(2019, time.June, 28, 17, 0, 0, 0, time.Local), false},
+		{"++!sprint-review", time.Date(2019, time.July, 26, 17, 0, 0, 0, time.Local), false},
+		{"!last-workday", time.Date(2019, time.July, 31, 0, 0, 0, 0, time.Local), false},
+		{"++!last-workday", time.Date(2019, time.August, 30, 0, 0, 0, 0, time.Local), false},
+		{"!turkey", time.Date(2019, time.November, 28, 0, 0, 0, 0, time.Local), false},
+		{"!twice", time.Date(2019, time.July, 5, 0, 0, 0, 0, time.Local), false},
+		{"!leap-day", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.Local), false},
+		{"++!leap-day", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.Local), false},
+		{"!leap-minute", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC), false},
+		{"++!leap-minute", time.Date(2020, time.February, 29, 0, 1, 0, 0, time.UTC), false},
+	})
+
+	testDirectives(t, ti.At(time.Date(2019, time.July, 4, 16, 0, 0, 0, time.UTC)), []directiveCase{
+		{"!every-7m", time.Date(2019, time.July, 4, 16, 6, 0, 0, time.UTC), false},
+		{"-!every-7m", time.Date(2019, time.July, 4, 15, 59, 0, 0, time.UTC), false},
+	})
+
+	var bad = []struct {
+		rule, exp string
+	}{
+		{"FREQ=MONTHLY;BYDAY=XX", "recurrence rule 'FREQ=MONTHLY;BYDAY=XX': invalid BYDAY 'XX'"},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", "recurrence rule 'FREQ=MONTHLY;BYMONTHDAY=32': invalid BYMONTHDAY '32'"},
+		{"FREQ=DAILY;BYHOUR=9", "recurrence rule 'FREQ=DAILY;BYHOUR=9': unsupported part 'BYHOUR'"},
+		{"INTERVAL=2", "recurrence rule 'INTERVAL=2': missing FREQ"},
+		{"FREQ=DAILY;COUNT=0", "recurrence rule 'FREQ=DAILY;COUNT=0': invalid COUNT '0'"},
+		{"FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=30", "recurrence rule 'FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=30': never occurs"},
+		{"FREQ=MONTHLY;BYDAY=6MO", "recurrence rule 'FREQ=MONTHLY;BYDAY=6MO': never occurs"},
+		{"FREQ=DAILY;BYSETPOS=2", "recurrence rule 'FREQ=DAILY;BYSETPOS=2': never occurs"},
+	}
+	for _, v := range bad {
+		if err := ti.SetRecurrence("x", v.rule); err == nil || err.Error() != v.exp {
+			t.Errorf("expected error '%s' but got error '%v'", v.exp, err)
+		}
+	}
+	testDirectives(t, ti, []directiveCase{
+		{"+++!twice", nilTime, true},
+		{"!last:leap-minute", nilTime, true},
+	})
+	if err := ti.SetRecurrence("today", "FREQ=DAILY"); err == nil {
+		t.Error("expected reserved key error setting today")
+	}
+}
+
+func TestCron(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor)
+	if err := ti.SetCron("backup", "0 3 * * 1-5"); err != nil {
+		t.Fatal(err.Error())
+	}
+	testDirectives(t, ti, []directiveCase{
+		{"!backup", time.Date(2019, time.July, 5, 3, 0, 0, 0, time.Local), false},
+		{"++!backup", time.Date(2019, time.July, 8, 3, 0, 0, 0, time.Local), false},
+		{"-!backup", time.Date(2019, time.July, 4, 3, 0, 0, 0, time.Local), false},
+		{">1h!cron:0 9 * * MON", time.Date(2019, time.July, 8, 10, 0, 0, 0, time.Local), false},
+		{"!last:cron:0 9 * * MON", time.Date(2019, time.July, 1, 9, 0, 0, 0, time.Local), false},
+		{"!cron:*/15 * * * *", time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local), false},
+		{"++!cron:*/15 * * * *", time.Date(2019, time.July, 4, 12, 15, 0, 0, time.Local), false},
+		{"!cron:30 0 12 * * *", time.Date(2019, time.July, 4, 12, 0, 30, 0, time.Local), false},
+		{"!cron:@monthly", time.Date(2019, time.August, 1, 0, 0, 0, 0, time.Local), false},
+		{"!cron:0 0 13 * FRI", time.Date(2019, time.July, 5, 0, 0, 0, 0, time.Local), false},
+		{"+++!cron:0 0 13 * FRI", time.Date(2019, time.July, 13, 0, 0, 0, 0, time.Local), false},
+		{"!cron:0 0 1 jan-mar/2 *", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local), false},
+	})
+
+	var bad = []struct {
+		expr, exp string
+	}{
+		{"0 25 * * *", "cron expression '0 25 * * *': hour field '25': '25' out of range 0-23"},
+		{"* * *", "cron expression '* * *': expected 5 or 6 fields, got 3"},
+		{"*/0 * * * *", "cron expression '*/0 * * * *': minute field '*/0': invalid step '0'"},
+		{"0 0 * * FRI-MON", "cron expression '0 0 * * FRI-MON': day of week field 'FRI-MON': range 'FRI-MON' ends before it starts"},
+	}
+	for _, v := range bad {
+		if err := ti.SetCron("x", v.expr); err == nil || err.Error() != v.exp {
+			t.Errorf("expected error '%s' but got error '%v'", v.exp, err)
+		}
+	}
+	testDirectives(t, ti, []directiveCase{
+		{"!cron:@daily", time.Date(2019, time.July, 5, 0, 0, 0, 0, time.Local), false},
+		{"!cron:0 25 * * *", nilTime, true},
+		{"!cron:0 0 30 2 *", nilTime, true},
+		{"+!cron:0 0 30 2 *", nilTime, true},
+	})
+}
+
+func TestOnCalendar(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor)
+	if err := ti.SetOnCalendar("standup", "Mon..Fri *-*-* 09:00:00"); err != nil {
+		t.Fatal(err.Error())
+	}
+	testDirectives(t, ti, []directiveCase{
+		{"!standup", time.Date(2019, time.July, 5, 9, 0, 0, 0, time.Local), false},
+		{"++!standup", time.Date(2019, time.July, 8, 9, 0, 0, 0, time.Local), false},
+		{"-!standup", time.Date(2019, time.July, 4, 9, 0, 0, 0, time.Local), false},
+		{"!oncalendar:*-*-01 00:00", time.Date(2019, time.August, 1, 0, 0, 0, 0, time.Local), false},
+		{"!oncalendar:weekly", time.Date(2019, time.July, 8, 0, 0, 0, 0, time.Local), false},
+		{"!oncalendar:quarterly", time.Date(2019, time.October, 1, 0, 0, 0, 0, time.Local), false},
+		{"++!oncalendar:*:0/20", time.Date(2019, time.July, 4, 12, 20, 0, 0, time.Local), false},
+		{"!oncalendar:*-02~01", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.Local), false},
+		{"!oncalendar:Sat,Sun 10:00", time.Date(2019, time.July, 6, 10, 0, 0, 0, time.Local), false},
+		{"!oncalendar:Fri..Mon 08:00", time.Date(2019, time.July, 5, 8, 0, 0, 0, time.Local), false},
+		{"!oncalendar:2021-01-01", time.Date(2021, time.January, 1, 0, 0, 0, 0, time.Local), false},
+		{"!oncalendar:*-*-* 09:00 UTC", time.Date(2019, time.July, 5, 9, 0, 0, 0, time.UTC), false},
+	})
+
+	var bad = []struct {
+		expr, exp string
+	}{
+		{"Mon..Fry", "calendar event 'Mon..Fry': weekdays 'Mon..Fry': unknown weekday 'Fry'"},
+		{"*-13-01", "calendar event '*-13-01': '*-13-01': '13' out of range 1..12"},
+		{"09:00 Mars/Olympus", "calendar event '09:00 Mars/Olympus': unknown timezone 'Mars/Olympus'"},
+		{"09:00 10:00", "calendar event '09:00 10:00': '10:00': unexpected"},
+	}
+	for _, v := range bad {
+		if err := ti.SetOnCalendar("x", v.expr); err == nil || err.Error() != v.exp {
+			t.Errorf("expected error '%s' but got error '%v'", v.exp, err)
+		}
+	}
+	testDirectives(t, ti, []directiveCase{{"!oncalendar:*-02-30", nilTime, true}})
+	past, _ := parseCalendar("2015-01-01 09:00 UTC")
+	if o, ok := past.next(anchor); ok || o != (time.Time{}) {
+		t.Errorf("next of a past calendar event expected the zero time, but got %v", o)
+	}
+	if o, ok := past.prev(time.Date(2010, time.January, 1, 0, 0, 0, 0, time.Local)); ok || o != (time.Time{}) {
+		t.Errorf("prev of a future calendar event expected the zero time, but got %v", o)
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor, HolidaysUS)
+	day := func(y int, m time.Month, d, h int) time.Time {
+		return time.Date(y, m, d, h, 0, 0, 0, time.Local)
+	}
+	var oc = []struct {
+		req      string
+		from, to time.Time
+		exp      []time.Time
+	}{
+		{">9h!monday", day(2019, time.July, 1, 0), day(2019, time.July, 29, 0),
+			[]time.Time{day(2019, time.July, 1, 9), day(2019, time.July, 8, 9), day(2019, time.July, 15, 9), day(2019, time.July, 22, 9)}},
+		{">2d!monday", day(2019, time.July, 3, 0), day(2019, time.July, 12, 0),
+			[]time.Time{day(2019, time.July, 3, 0), day(2019, time.July, 10, 0)}},
+		{"!christmas", day(2019, time.January, 1, 0), day(2022, time.January, 1, 0),
+			[]time.Time{day(2019, time.December, 25, 12), day(2020, time.December, 25, 12), day(2021, time.December, 25, 12)}},
+		{"<1d!christmas", day(2019, time.December, 24, 13), day(2021, time.January, 1, 0),
+			[]time.Time{day(2020, time.December, 24, 12)}},
+		{"!cron:0 12 * * SAT,SUN", day(2019, time.July, 1, 0), day(2019, time.July, 10, 0),
+			[]time.Time{day(2019, time.July, 6, 12), day(2019, time.July, 7, 12)}},
+		{"!july 4 2019", day(2019, time.July, 1, 0), day(2019, time.August, 1, 0),
+			[]time.Time{day(2019, time.July, 4, 0)}},
+		{"!july 4 2019", day(2019, time.August, 1, 0), day(2019, time.September, 1, 0), nil},
+	}
+	for _, v := range oc {
+		var got []time.Time
+		for o := range ti.Occurrences(v.req, v.from, v.to) {
+			got = append(got, o)
+		}
+		if len(got) != len(v.exp) {
+			t.Errorf("%s expected %v, but got %v", strings.ToUpper(v.req), v.exp, got)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(v.exp[i]) {
+				t.Errorf("%s expected %v, but got %v", strings.ToUpper(v.req), v.exp, got)
+				break
+			}
+		}
+	}
+
+	for range ti.Occurrences(">3x!tuesday", anchor, anchor.AddDate(0, 1, 0)) {
+		t.Error(">3X!TUESDAY expected no occurrences of a malformed directive")
+		break
+	}
+	if _, err := ti.OccurrencesE(">3x!tuesday", anchor, anchor.AddDate(0, 1, 0)); err == nil {
+		t.Error(">3X!TUESDAY expected error but got none")
+	}
+
+	var n int
+	for range ti.Occurrences("!cron:@daily", anchor, anchor.AddDate(100, 0, 0)) {
+		if n++; n == 3 {
+			break
+		}
+	}
+	if n != 3 {
+		t.Errorf("expected to stop after 3 occurrences, but got %d", n)
+	}
+	if got := ti.Time; !got.Equal(anchor) {
+		t.Errorf("expected instance time unchanged at %v, but got %v", anchor, got)
+	}
+}
+
+func TestGetInterval(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor, HolidaysUS)
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+	var iv = []struct {
+		req string
+		exp Interval
+	}{
+		{"!today", Interval{day(2019, time.July, 4), day(2019, time.July, 5)}},
+		{"!quarter:3", Interval{day(2019, time.July, 1), day(2019, time.October, 1)}},
+		{"!quarter:2", Interval{day(2020, time.April, 1), day(2020, time.July, 1)}},
+		{"!q3", Interval{day(2019, time.July, 1), day(2019, time.October, 1)}},
+		{"!q4", Interval{day(2019, time.October, 1), day(2020, time.January, 1)}},
+		{"!eom", Interval{day(2019, time.July, 1), day(2019, time.August, 1)}},
+		{"!som", Interval{day(2019, time.August, 1), day(2019, time.September, 1)}},
+		{"!eoq", Interval{day(2019, time.July, 1), day(2019, time.October, 1)}},
+		{"!eoy", Interval{day(2019, time.January, 1), day(2020, time.January, 1)}},
+		{"!monday", Interval{day(2019, time.July, 8), day(2019, time.July, 9)}},
+		{"++!monday", Interval{day(2019, time.July, 15), day(2019, time.July, 16)}},
+		{"!eoww", Interval{day(2019, time.July, 1), day(2019, time.July, 6)}},
+		{"!eow", Interval{day(2019, time.June, 30), day(2019, time.July, 7)}},
+		{"!august", Interval{day(2019, time.August, 1), day(2019, time.September, 1)}},
+		{"!thanksgiving", Interval{day(2019, time.November, 28), day(2019, time.November, 29)}},
+		{">1w!christmas", Interval{day(2020, time.January, 1), day(2020, time.January, 2)}},
+		{"!now", Interval{anchor, anchor}},
+	}
+	for _, v := range iv {
+		if got := ti.GetInterval(v.req); !got.Start.Equal(v.exp.Start) || !got.End.Equal(v.exp.End) {
+			t.Errorf("%s expected %v, but got %v", strings.ToUpper(v.req), v.exp, got)
+		}
+	}
+	testDirectives(t, ti, []directiveCase{
+		{"!quarter:3", day(2019, time.July, 1), false},
+		{"!quarter:5", nilTime, true},
+	})
+	if err := ti.Set("q1", "!july 1 2019"); err != nil {
+		t.Errorf("expected q1 settable, but got error '%v'", err)
+	}
+	testDirectives(t, ti, []directiveCase{{"!q1", day(2019, time.July, 1), false}})
+	if err := ti.SetRecurrence("once", "DTSTART:20190101T000000\nRRULE:FREQ=DAILY;COUNT=1"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := ti.GetIntervalE("!once"); err == nil {
+		t.Error("!ONCE expected no occurrence error but got none")
+	}
+	today := ti.GetInterval("!today")
+	if !today.Contains(anchor) || today.Contains(today.End) || today.Duration() != 24*time.Hour || today.IsZero() {
+		t.Errorf("unexpected interval behaviour of %v", today)
+	}
+	if _, err := ti.GetIntervalE("!not a point"); err == nil {
+		t.Error("expected error for an unknown point")
+	}
+}
+
+func TestIntervalAlgebra(t *testing.T) {
+	at := func(h, m int) time.Time {
+		return time.Date(2019, time.July, 4, h, m, 0, 0, time.Local)
+	}
+	busy := []Interval{
+		{at(9, 0), at(10, 0)},
+		{at(13, 0), at(14, 0)},
+		{at(9, 30), at(11, 0)},
+		{at(16, 0), at(16, 0)},
+		{at(11, 0), at(11, 30)},
+	}
+	day := Interval{at(8, 0), at(18, 0)}
+	var ia = []struct {
+		name     string
+		got, exp []Interval
+	}{
+		{"merge", Merge(busy), []Interval{{at(9, 0), at(11, 30)}, {at(13, 0), at(14, 0)}}},
+		{"intersect", Intersect(busy, []Interval{{at(10, 0), at(13, 30)}, {at(15, 0), at(17, 0)}}),
+			[]Interval{{at(10, 0), at(11, 30)}, {at(13, 0), at(13, 30)}}},
+		{"subtract", Subtract([]Interval{day}, busy),
+			[]Interval{{at(8, 0), at(9, 0)}, {at(11, 30), at(13, 0)}, {at(14, 0), at(18, 0)}}},
+		{"complement", Complement(busy, Interval{at(10, 0), at(15, 0)}),
+			[]Interval{{at(11, 30), at(13, 0)}, {at(14, 0), at(15, 0)}}},
+		{"gaps", Gaps(busy, day, 2*time.Hour), []Interval{{at(14, 0), at(18, 0)}}},
+		{"gaps", Gaps(busy, day, time.Hour),
+			[]Interval{{at(8, 0), at(9, 0)}, {at(11, 30), at(13, 0)}, {at(14, 0), at(18, 0)}}},
+		{"complement", Complement(nil, day), []Interval{day}},
+	}
+
+	ti := anchored(t, at(12, 0))
+	ia = append(ia, struct {
+		name     string
+		got, exp []Interval
+	}{"gaps", Gaps([]Interval{ti.GetInterval("!tomorrow")}, ti.GetInterval("!eow"), 24*time.Hour),
+		[]Interval{{at(0, 0).AddDate(0, 0, -4), at(0, 0).AddDate(0, 0, 1)}, {at(0, 0).AddDate(0, 0, 2), at(0, 0).AddDate(0, 0, 3)}}})
+
+	for _, v := range ia {
+		same := len(v.got) == len(v.exp)
+		for i := 0; same && i < len(v.got); i++ {
+			same = v.got[i].Start.Equal(v.exp[i].Start) && v.got[i].End.Equal(v.exp[i].End)
+		}
+		if !same {
+			t.Errorf("%s expected %v, but got %v", v.name, v.exp, v.got)
+		}
+	}
+	if !busy[0].Overlaps(busy[2]) || busy[0].Overlaps(busy[4]) {
+		t.Error("unexpected overlap")
+	}
+}
+
+func TestBetween(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor)
+	var bt = []struct {
+		a, b                                   string
+		y, m, d                                int
+		dur                                    time.Duration
+		totalMonths, totalWeeks, totalDays, bd int
+	}{
+		{"!now", ">1y>2mo>11d>6h30m!", 1, 2, 11, 6*time.Hour + 30*time.Minute, 14, 62, 439, 313},
+		{">1y>2mo>11d>6h30m!", "!now", -1, -2, -11, -(6*time.Hour + 30*time.Minute), -14, -62, -439, -313},
+		{"!now", ">1w!", 0, 0, 7, 0, 0, 1, 7, 5},
+		{"!2019-01-31", "!2019-03-01", 0, 0, 29, 0, 0, 4, 29, 21},
+		{"!2019-01-31", "!2019-02-28", 0, 0, 28, 0, 0, 4, 28, 20},
+		{"!2019-01-31", "!2019-03-03", 0, 1, 0, 0, 1, 4, 31, 22},
+		{"!2019-03-31", "!2019-02-28", 0, -1, -3, 0, -1, -4, -31, -22},
+		{"!2019-03-09 12:00", "!2019-03-10 12:00", 0, 0, 1, 0, 0, 0, 1, 0},
+		{"!2019-01-31 23:30", "!2019-03-01 00:30", 0, 1, 0, time.Hour, 1, 4, 28, 21},
+		{"!2019-03-01 00:30", "!2019-01-31 23:30", 0, 0, -28, -time.Hour, 0, -4, -28, -21},
+		{"!now", "!now", 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+	for _, v := range bt {
+		got := ti.Between(v.a, v.b)
+		if got.Years != v.y || got.Months != v.m || got.Days != v.d || got.Duration != v.dur ||
+			got.TotalMonths != v.totalMonths || got.TotalWeeks != v.totalWeeks || got.TotalDays != v.totalDays || got.BusinessDays != v.bd {
+			t.Errorf("BETWEEN %s AND %s got %+v", strings.ToUpper(v.a), strings.ToUpper(v.b), got)
+		}
+		if !got.Period.AddTo(got.From).Equal(got.To) {
+			t.Errorf("BETWEEN %s AND %s: %v plus %v expected %v, but got %v", strings.ToUpper(v.a), strings.ToUpper(v.b), got.From, got.Period, got.To, got.Period.AddTo(got.From))
+		}
+	}
+	if _, err := ti.BetweenE("!now", "!not a point"); err == nil {
+		t.Error("expected error for an unknown point")
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	var pp = []struct {
+		in  string
+		exp Period
+		str string
+	}{
+		{"P1Y2M3DT4H5M", Period{1, 2, 3, 4*time.Hour + 5*time.Minute}, "P1Y2M3DT4H5M"},
+		{"P2W", Period{0, 0, 14, 0}, "P14D"},
+		{"-PT1.5H", Period{0, 0, 0, -90 * time.Minute}, "-PT1H30M"},
+		{"PT0,5S", Period{0, 0, 0, 500 * time.Millisecond}, "PT0.5S"},
+		{"P1DT6.5S", Period{0, 0, 1, 6500 * time.Millisecond}, "P1DT6.5S"},
+		{"P0D", Period{}, "PT0S"},
+		{"P1W1DT1H1M1S", Period{0, 0, 8, time.Hour + time.Minute + time.Second}, "P8DT1H1M1S"},
+	}
+	for _, v := range pp {
+		got, err := ParsePeriod(v.in)
+		if err != nil {
+			t.Errorf("%s unexpected error %v", v.in, err)
+			continue
+		}
+		if got != v.exp {
+			t.Errorf("%s expected %+v, but got %+v", v.in, v.exp, got)
+		}
+		if got.String() != v.str {
+			t.Errorf("%s expected string %s, but got %s", v.in, v.str, got.String())
+		}
+	}
+	for _, bad := range []string{"P", "1D", "PT", "P1.5D", "--P1D", "+-P1D", "P-", "P--1D", "P1H", "PT1D", "PT1.5H30M", "P1DT",
+		"P1D1Y", "P1D1D", "PT1S1M", "PT1H1H", "P1M1W1Y",
+		"P99999999999999999999Y", "P9223372036854775807W", "P1W9223372036854775807D",
+		"PT2562048H", "PT9999999999999999999S", "PT2562047H60M"} {
+		if _, err := ParsePeriod(bad); err == nil {
+			t.Errorf("%s expected error", bad)
+		}
+	}
+
+	p := Period{0, 14, 0, 30 * time.Hour}
+	if got, exp := p.Normalize(), (Period{1, 2, 1, 6 * time.Hour}); got != exp {
+		t.Errorf("normalize expected %+v, but got %+v", exp, got)
+	}
+	if got, exp := p.Add(p.Negate()), (Period{}); got != exp || !got.IsZero() {
+		t.Errorf("add negated expected %+v, but got %+v", exp, got)
+	}
+	for _, v := range []struct {
+		p   Period
+		exp string
+	}{
+		{Period{0, 1, -1, 0}, "P1M-1D"},
+		{Period{0, 0, 1, -90 * time.Minute}, "P1DT-1H-30M"},
+		{Period{-1, 0, 0, 1500 * time.Millisecond}, "P-1YT1.5S"},
+	} {
+		if got := v.p.String(); got != v.exp {
+			t.Errorf("mixed signs expected %s, but got %s", v.exp, got)
+		}
+		if got, err := ParsePeriod(v.p.String()); err != nil || got != v.p {
+			t.Errorf("%s expected to parse back to %+v, but got %+v (%v)", v.exp, v.p, got, err)
+		}
+	}
+	jan := time.Date(2019, time.January, 31, 23, 30, 0, 0, time.UTC)
+	if got, exp := (Period{0, 1, 0, time.Hour}).AddTo(jan), time.Date(2019, time.March, 1, 0, 30, 0, 0, time.UTC); !got.Equal(exp) {
+		t.Errorf("add to expected the clock added first, %v, but got %v", exp, got)
+	}
+
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor)
+	testDirectives(t, ti, []directiveCase{
+		{">P2W!now", anchor.AddDate(0, 0, 14), false},
+		{"<P1DT2H!", anchor.AddDate(0, 0, -1).Add(-2 * time.Hour), false},
+		{">P1X!", nilTime, true},
+	})
+	if got, exp := ti.Duration(">PT90M"), 90*time.Minute; got != exp {
+		t.Errorf(">PT90M expected %v, but got %v", exp, got)
+	}
+	if got, exp := ti.Between("!now", ">1y>2mo>11d>6h30m!").String(), "P1Y2M11DT6H30M"; got != exp {
+		t.Errorf("between expected %s, but got %s", exp, got)
+	}
+	ti.Rebase(time.Date(2019, time.January, 31, 23, 30, 0, 0, time.UTC))
+	testDirectives(t, ti, []directiveCase{
+		{">1mo1h!now", time.Date(2019, time.March, 1, 0, 30, 0, 0, time.UTC), false},
+		{">P1MT1H!now", time.Date(2019, time.March, 1, 0, 30, 0, 0, time.UTC), false},
+	})
+}
+
+func TestISOInterval(t *testing.T) {
+	anchor := time.Date(2019, time.July, 4, 12, 0, 0, 0, time.Local)
+	ti := anchored(t, anchor)
+	day := func(d int) time.Time {
+		return time.Date(2019, time.July, d, 0, 0, 0, 0, time.Local)
+	}
+	edt := time.FixedZone("", -4*60*60)
+	eod := time.Date(2019, time.July, 4, 23, 59, 59, 0, time.Local)
+	var pi = []struct {
+		in  string
+		exp Interval
+	}{
+		{"2019-07-04T00:00Z/P1W", Interval{time.Date(2019, time.July, 4, 0, 0, 0, 0, time.UTC), time.Date(2019, time.July, 11, 0, 0, 0, 0, time.UTC)}},
+		{"2019-07-04/2019-07-06", Interval{day(4), day(6)}},
+		{"P1D/2019-07-04T17:00-04:00", Interval{time.Date(2019, time.July, 3, 17, 0, 0, 0, edt), time.Date(2019, time.July, 4, 17, 0, 0, 0, edt)}},
+		{"!now/P1W", Interval{anchor, anchor.AddDate(0, 0, 7)}},
+		{"../P1D", Interval{anchor, anchor.AddDate(0, 0, 1)}},
+		{"P1D/!eod", Interval{eod.AddDate(0, 0, -1), eod}},
+		{"!today/!friday", Interval{day(4), day(5)}},
+		{"!cron:*/15 * * * */PT1H", Interval{anchor, anchor.Add(time.Hour)}},
+		{"!7/4/2019/P1D", Interval{day(4), day(5)}},
+		{`!"7/4/2019"/!"7/6/2019"`, Interval{day(4), day(6)}},
+		{"2019-07-04/!7/6/2019", Interval{day(4), day(6)}},
+	}
+	for _, v := range pi {
+		got, err := ti.ParseInterval(v.in)
+		if err != nil || !got.Start.Equal(v.exp.Start) || !got.End.Equal(v.exp.End) {
+			t.Errorf("%s expected %v, but got %v, error %v", v.in, v.exp, got, err)
+		}
+	}
+	for _, bad := range []string{"P1D/P2D", "2019-07-04", "!not a point/P1D", "2019-07-06/2019-07-04"} {
+		if _, err := ti.ParseInterval(bad); err == nil {
+			t.Errorf("%s expected error", bad)
+		}
+	}
+
+	var pr = []struct {
+		in     string
+		starts []time.Time
+	}{
+		{"R5/2019-07-04T09:00Z/P1W", []time.Time{
+			time.Date(2019, time.July, 4, 9, 0, 0, 0, time.UTC), time.Date(2019, time.July, 11, 9, 0, 0, 0, time.UTC),
+			time.Date(2019, time.July, 18, 9, 0, 0, 0, time.UTC), time.Date(2019, time.July, 25, 9, 0, 0, 0, time.UTC),
+			time.Date(2019, time.August, 1, 9, 0, 0, 0, time.UTC)}},
+		{"R/!monday/P1W", []time.Time{day(8), day(15), day(22)}},
+		{"R3/P1D/2019-07-04", []time.Time{day(3), day(2), day(1)}},
+		{"R2/2019-07-01/2019-07-03", []time.Time{day(1), day(3)}},
+		{"R2/!cron:0 9 */2 * */P1D", []time.Time{time.Date(2019, time.July, 5, 9, 0, 0, 0, time.Local), time.Date(2019, time.July, 6, 9, 0, 0, 0, time.Local)}},
+	}
+	for _, v := range pr {
+		r, err := ti.ParseRepeating(v.in)
+		if err != nil {
+			t.Errorf("%s unexpected error %v", v.in, err)
+			continue
+		}
+		var got []time.Time
+		for o := range r.Occurrences() {
+			if got = append(got, o); len(got) == len(v.starts)+1 {
+				break
+			}
+		}
+		if r.Count < 0 {
+			got = got[:len(v.starts)]
+		}
+		same := len(got) == len(v.starts)
+		for i := 0; same && i < len(got); i++ {
+			same = got[i].Equal(v.starts[i])
+		}
+		if !same {
+			t.Errorf("%s expected %v, but got %v", v.in, v.starts, got)
+		}
+	}
+	r, _ := ti.ParseRepeating("R3/P1D/2019-07-04")
+	for i := range r.Intervals() {
+		if i.Duration() != 24*time.Hour {
+			t.Errorf("expected intervals of a day, but got %v", i)
+		}
+	}
+	for _, bad := range []string{"R5", "Rx/2019-07-04/P1D", "R/2019-07-04/2019-07-04"} {
+		if _, err := ti.ParseRepeating(bad); err == nil {
+			t.Errorf("%s expected error", bad)
+		}
+	}
+}
+
+func TestMonthEnd(t *testing.T) {
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 12, 0, 0, 0, time.Local)
+	}
+	var me = []struct {
+		cnf    []Config
+		anchor time.Time
+		req    string
+		exp    time.Time
+	}{
+		{nil, at(2019, time.January, 31), ">1mo!", at(2019, time.March, 3)},
+		{nil, at(2019, time.January, 31), "@clamp>1mo!", at(2019, time.February, 28)},
+		{nil, at(2019, time.January, 31), "@eom>1mo!", at(2019, time.February, 28)},
+		{nil, at(2019, time.February, 28), "@eom>1mo!", at(2019, time.March, 31)},
+		{nil, at(2019, time.February, 28), "@clamp>1mo!", at(2019, time.March, 28)},
+		{nil, at(2019, time.March, 31), "@clamp<1mo!", at(2019, time.February, 28)},
+		{nil, at(2020, time.February, 29), ">1y!", at(2021, time.March, 1)},
+		{nil, at(2020, time.February, 29), "@clamp>1y!", at(2021, time.February, 28)},
+		{nil, at(2019, time.February, 28), "@eom>1y>1mo!", at(2020, time.March, 31)},
+		{nil, at(2019, time.January, 30), "@clamp>1mo12h!", time.Date(2019, time.February, 28, 0, 0, 0, 0, time.Local)},
+		{[]Config{WithMonthEnd(MonthClamp)}, at(2019, time.January, 31), ">1mo!", at(2019, time.February, 28)},
+		{[]Config{WithMonthEnd(MonthClamp)}, at(2019, time.January, 31), "@overflow>1mo!", at(2019, time.March, 3)},
+		{[]Config{WithMonthEnd(MonthPreserveEOM)}, at(2019, time.April, 30), ">>1mo!", at(2019, time.June, 30)},
+	}
+	for _, v := range me {
+		testDirectives(t, anchored(t, v.anchor, v.cnf...), []directiveCase{{v.req, v.exp, false}})
+	}
+	if d, err := Compile("@clamp>1mo!"); err != nil || d.String() != "@clamp>1mo!now" {
+		t.Errorf("expected @clamp>1mo!now, but got %v, error %v", d, err)
+	}
+	for _, bad := range []string{"@bogus>1mo!", "@clamp@eom>1mo!"} {
+		if _, err := Compile(bad); err == nil {
+			t.Errorf("%s expected error", bad)
+		}
+	}
+}
+
+func TestWallClock(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err.Error())
+	}
+	at := func(m time.Month, d, h, mi int) time.Time {
+		return time.Date(2019, m, d, h, mi, 0, 0, ny)
+	}
+	utc := func(m time.Month, d, h, mi int) time.Time {
+		return time.Date(2019, m, d, h, mi, 0, 0, time.UTC)
+	}
+	var wc = []struct {
+		cnf    []Config
+		anchor time.Time
+		req    string
+		exp    time.Time
+		fails  bool
+	}{
+		{nil, at(time.March, 9, 12, 0), ">24h!", at(time.March, 10, 13, 0), false},
+		{nil, at(time.March, 9, 12, 0), ">1d!", at(time.March, 10, 12, 0), false},
+		{nil, at(time.March, 9, 12, 0), ">24wh!", at(time.March, 10, 12, 0), false},
+		{[]Config{WithClockMode(ClockWall)}, at(time.March, 9, 12, 0), ">24h!", at(time.March, 10, 12, 0), false},
+		{[]Config{WithClockMode(ClockWall)}, at(time.March, 9, 12, 0), ">24ah!", at(time.March, 10, 13, 0), false},
+		{nil, at(time.March, 9, 2, 30), ">1d!", utc(time.March, 10, 7, 30), false},
+		{[]Config{WithLocalTime(LocalEarlier)}, at(time.March, 9, 2, 30), ">1d!", utc(time.March, 10, 6, 30), false},
+		{[]Config{WithLocalTime(LocalLater)}, at(time.March, 9, 2, 30), ">1d!", utc(time.March, 10, 7, 30), false},
+		{[]Config{WithLocalTime(LocalError)}, at(time.March, 9, 2, 30), ">1d!", utc(time.March, 10, 7, 30), true},
+		{nil, at(time.November, 2, 1, 30), ">1d!", utc(time.November, 3, 5, 30), false},
+		{[]Config{WithLocalTime(LocalLater)}, at(time.November, 2, 1, 30), ">1d!", utc(time.November, 3, 6, 30), false},
+		{[]Config{WithLocalTime(LocalError)}, at(time.November, 2, 1, 30), ">1d!", utc(time.November, 3, 5, 30), true},
+		{nil, at(time.November, 3, 0, 30), ">1wh!", utc(time.November, 3, 5, 30), false},
+		{nil, at(time.November, 3, 0, 30), ">2wh!", utc(time.November, 3, 7, 30), false},
+		{nil, at(time.November, 3, 0, 30), ">2h!", utc(time.November, 3, 6, 30), false},
+		{nil, at(time.November, 3, 0, 30), ">90wm!", utc(time.November, 3, 7, 0), false},
+		{nil, at(time.November, 3, 0, 30), ">1.5wh!", utc(time.November, 3, 7, 0), false},
+	}
+	for _, v := range wc {
+		dc := directiveCase{v.req, v.exp, v.fails}
+		testDirectives(t, anchored(t, v.anchor, v.cnf...), []directiveCase{dc, dc})
+	}
+	for _, bad := range []string{">1xh!", ">1.5xh!"} {
+		if _, err := Compile(bad); err == nil {
+			t.Errorf("%s expected error", bad)
+		}
+	}
+	if d, err := Compile(">1.5h!"); err != nil {
+		t.Errorf(">1.5h! unexpected error %v", err)
+	} else if got, exp := d.shifters()[0].Duration, 90*time.Minute; got != exp {
+		t.Errorf(">1.5h! expected %v, but got %v", exp, got)
+	}
+}
+
 func TestRing(t *testing.T) {
 	ringTest := []string{"one", "two", "three", "four", "five"}
 	rt := ring(ringTest)
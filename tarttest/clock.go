@@ -0,0 +1,39 @@
+// Package tarttest provides helpers for testing code built on tart.
+package tarttest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake tart.Clock reporting a fixed time, moved only when told.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock fixed at the provided time.
+func NewClock(t time.Time) *Clock {
+	return &Clock{now: t}
+}
+
+// Now returns the time the Clock is fixed at.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set fixes the Clock at the provided time.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the Clock by the provided duration.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
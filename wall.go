@@ -0,0 +1,157 @@
+package tart
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClockMode is how shifts by clock units, hours, minutes and seconds, move a
+// time across daylight saving changes.
+type ClockMode int
+
+const (
+	// ClockAbsolute moves by elapsed time: 24 hours on from noon the day
+	// before clocks go forward is 13:00.
+	ClockAbsolute ClockMode = iota + 1
+	// ClockWall moves the wall clock: 24 hours on from noon the day before
+	// clocks go forward is noon, as a day on is.
+	ClockWall
+)
+
+// LocalTime is the policy for shifts landing on a local time that does not
+// exist, skipped as clocks go forward, or is ambiguous, repeated as clocks go
+// back.
+type LocalTime int
+
+const (
+	// LocalCompatible takes the later time for a nonexistent time, moving
+	// on by the length of the skip, e.g. 02:30 as 03:30, and the earlier
+	// for an ambiguous time, the first 01:30.
+	LocalCompatible LocalTime = iota + 1
+	// LocalEarlier takes the earlier time, e.g. 02:30 as 01:30 before
+	// clocks go forward.
+	LocalEarlier
+	// LocalLater takes the later time, e.g. the second 01:30 as clocks go
+	// back.
+	LocalLater
+	// LocalError takes the time LocalCompatible does, failing evaluation
+	// with an error, reported by GetE.
+	LocalError
+)
+
+// WithClockMode sets the ClockMode of shifts by the clock units 'h', 'm', 's',
+// etc, ClockAbsolute by default. The units prefixed 'w', e.g. 'wh', always
+// move the wall clock, and those prefixed 'a', e.g. 'ah', always move by
+// elapsed time.
+func WithClockMode(m ClockMode) Config {
+	return func(t *Tart) error {
+		t.clockMode = m
+		return nil
+	}
+}
+
+// WithLocalTime sets the LocalTime policy of shifts landing on a local time
+// that does not exist or is ambiguous, LocalCompatible by default.
+func WithLocalTime(l LocalTime) Config {
+	return func(t *Tart) error {
+		t.localTime = l
+		return nil
+	}
+}
+
+// date returns the time of the provided wall clock in the provided location,
+// by the policy where it does not exist or is ambiguous.
+func (l LocalTime) date(y int, mo time.Month, d, h, mi, s, ns int, loc *time.Location) (time.Time, error) {
+	naive := time.Date(y, mo, d, h, mi, s, ns, time.UTC)
+	_, o1 := naive.Add(-24 * time.Hour).In(loc).Zone()
+	_, o2 := naive.Add(24 * time.Hour).In(loc).Zone()
+	e1 := naive.Add(-time.Duration(o1) * time.Second).In(loc)
+	e2 := naive.Add(-time.Duration(o2) * time.Second).In(loc)
+	if e2.Before(e1) {
+		e1, e2 = e2, e1
+	}
+	v1, v2 := wallEqual(e1, naive), wallEqual(e2, naive)
+	switch {
+	case o1 == o2 || (v1 && !v2):
+		return e1, nil
+	case v2 && !v1:
+		return e2, nil
+	case v1 && v2:
+		if l == LocalLater {
+			return e2, nil
+		}
+		if l == LocalError {
+			return e1, fmt.Errorf("local time %s is ambiguous in %s", naive.Format("2006-01-02 15:04:05"), loc)
+		}
+		return e1, nil
+	}
+	if l == LocalEarlier {
+		return e1, nil
+	}
+	if l == LocalError {
+		return e2, fmt.Errorf("local time %s does not exist in %s", naive.Format("2006-01-02 15:04:05"), loc)
+	}
+	return e2, nil
+}
+
+// wallEqual reports whether the wall clock of the provided time is that of
+// the provided naive time, a wall clock in UTC.
+func wallEqual(t, naive time.Time) bool {
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	return naive.Equal(time.Date(y, mo, d, h, mi, s, t.Nanosecond(), time.UTC))
+}
+
+// policy is the MonthEnd, ClockMode and LocalTime policies a shift is made
+// by.
+type policy struct {
+	monthEnd MonthEnd
+	mode     ClockMode
+	local    LocalTime
+}
+
+// policy returns the policies of the directive of the Context, else those of
+// the Tart instance.
+func (c *Context) policy() policy {
+	p := policy{MonthOverflow, ClockAbsolute, LocalCompatible}
+	if c.Tart != nil {
+		p = policy{c.Tart.monthEnd, c.Tart.clockMode, c.Tart.localTime}
+	}
+	if c.Directive != nil && c.Directive.monthEnd != 0 {
+		p.monthEnd = c.Directive.monthEnd
+	}
+	return p
+}
+
+// addDate returns the provided time moved by the provided years, months and
+// days, keeping the wall clock.
+func (p policy) addDate(t time.Time, years, months, days int) (time.Time, error) {
+	if years == 0 && months == 0 && days == 0 {
+		return t, nil
+	}
+	y, mo, d := t.Date()
+	if (p.monthEnd == MonthClamp || p.monthEnd == MonthPreserveEOM) && (years != 0 || months != 0) {
+		eom := d == lastDay(y, mo)
+		f := time.Date(y, mo+time.Month(12*years+months), 1, 0, 0, 0, 0, time.UTC)
+		if last := lastDay(f.Year(), f.Month()); d > last || (eom && p.monthEnd == MonthPreserveEOM) {
+			d = last
+		}
+		y, mo, years, months = f.Year(), f.Month(), 0, 0
+	}
+	n := time.Date(y+years, mo+time.Month(months), d+days, 0, 0, 0, 0, time.UTC)
+	h, mi, s := t.Clock()
+	return p.local.date(n.Year(), n.Month(), n.Day(), h, mi, s, t.Nanosecond(), t.Location())
+}
+
+// addClock returns the provided time moved by the provided duration, by the
+// provided ClockMode.
+func (p policy) addClock(t time.Time, d time.Duration, m ClockMode) (time.Time, error) {
+	if d == 0 || m != ClockWall {
+		return t.Add(d), nil
+	}
+	y, mo, dd := t.Date()
+	h, mi, s := t.Clock()
+	n := time.Date(y, mo, dd, h, mi, s, t.Nanosecond(), time.UTC).Add(d)
+	h, mi, s = n.Clock()
+	return p.local.date(n.Year(), n.Month(), n.Day(), h, mi, s, n.Nanosecond(), t.Location())
+}